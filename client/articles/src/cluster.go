@@ -538,6 +538,8 @@ func (a *App) sortSourcesByCluster() {
 
 		log.Printf("[CLUSTERING] Cluster %d: %d central, %d outliers", clusterID, len(centralPoints), len(outliers))
 		// Combine central points first, then outliers
+		sortByTier(centralPoints)
+		sortByTier(outliers)
 		clusterGroups[clusterID] = append(centralPoints, outliers...)
 	}
 
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strings"
 	"log"
+	"unicode"
 	"github.com/adrg/strutil/metrics"
 )
 
@@ -80,6 +81,63 @@ func filterSources(sources []Source) ([]Source, error) {
 	return filtered_sources, nil
 }
 
+// titleWords are the words of a title worth comparing
+func titleWords(title string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) > 3 {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// sameHeadline is true if most of the words of the shorter title are in the other one, as in the server's lib/resolve
+func sameHeadline(words_a map[string]bool, words_b map[string]bool) bool {
+	if len(words_a) == 0 || len(words_b) == 0 {
+		return false
+	}
+	shared := 0
+	for word := range words_a {
+		if words_b[word] {
+			shared++
+		}
+	}
+	return float64(shared) >= 0.6*float64(min(len(words_a), len(words_b)))
+}
+
+// Low-trust sites are only shown once a second outlet has the same story. They aren't marked as processed,
+// so that they show up later if they do get corroborated.
+func hideUncorroboratedLowTrust(sources []Source) []Source {
+	// Worked out once per source, rather than for every pair
+	hosts := make([]string, len(sources))
+	words := make([]map[string]bool, len(sources))
+	for i, source := range sources {
+		hosts[i] = getShortHost(source.Link)
+		words[i] = titleWords(source.Title)
+	}
+	is_reported_elsewhere := func(i int) bool {
+		for j := range sources {
+			if i != j && hosts[i] != hosts[j] && sameHeadline(words[i], words[j]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var shown_sources []Source
+	for i, source := range sources {
+		if source.Tier != "low" || source.Corroborated || is_reported_elsewhere(i) {
+			shown_sources = append(shown_sources, source)
+		} else {
+			log.Printf("Hiding uncorroborated low-trust source: %s", source.Title)
+		}
+	}
+	return shown_sources
+}
+
 func filterSourcesForUnread(sources []Source) []Source {
 	var unread_sources []Source
 	for _, source := range sources {
@@ -106,7 +164,14 @@ func skipSourcesWithSimilarityMetric(sources []Source) ([]Source, error) {
 			hamming := metrics.NewHamming()
 			distance := hamming.Distance(title_i[:30], last_title[:30])
 			if distance <= 4 {
-				go markProcessedInServer(true, sources[i].ID, sources[i])
+				// Keep whichever of the two is from the more trusted site
+				last := len(new_sources) - 1
+				if tierRank(sources[i].Tier) > tierRank(new_sources[last].Tier) {
+					go markProcessedInServer(true, new_sources[last].ID, new_sources[last])
+					new_sources[last] = sources[i]
+				} else {
+					go markProcessedInServer(true, sources[i].ID, sources[i])
+				}
 				continue
 			} 
 			last_title = title_i
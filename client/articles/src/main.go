@@ -67,7 +67,10 @@ func (a *App) loadSources() error {
 	defer conn.Close(ctx)
 
 	// rows, err := conn.Query(ctx, "SELECT id, title, link, date, summary, importance_bool, importance_reasoning, created_at, processed FROM sources WHERE processed = false AND EXTRACT('week' from date) = 22 ORDER BY date ASC, id ASC") // AND DATE_PART('doy', date) < 34
-	rows, err := conn.Query(ctx, "SELECT id, title, link, date, summary, importance_bool, importance_reasoning, created_at, processed FROM sources WHERE processed = false ORDER BY date ASC, id ASC") 
+	rows, err := conn.Query(ctx, `SELECT id, title, link, date, summary, importance_bool, importance_reasoning, created_at, processed, tier,
+		EXISTS (SELECT 1 FROM story_events e1 JOIN story_events e2 ON e2.story_id = e1.story_id
+			WHERE e1.link = sources.link AND split_part(e2.link, '/', 3) <> split_part(e1.link, '/', 3)) AS corroborated
+		FROM sources WHERE processed = false ORDER BY date ASC, id ASC`)
 	// AND DATE_PART('doy', date) < 35
 	// AND date < '2025-09-08'
 	// date '+%j'
@@ -79,7 +82,7 @@ func (a *App) loadSources() error {
 	var sources []Source
 	for rows.Next() {
 		var s Source
-		err := rows.Scan(&s.ID, &s.Title, &s.Link, &s.Date, &s.Summary, &s.ImportanceBool, &s.ImportanceReasoning, &s.CreatedAt, &s.Processed)
+		err := rows.Scan(&s.ID, &s.Title, &s.Link, &s.Date, &s.Summary, &s.ImportanceBool, &s.ImportanceReasoning, &s.CreatedAt, &s.Processed, &s.Tier, &s.Corroborated)
 		if err != nil {
 			return fmt.Errorf("failed to scan row: %v", err)
 		}
@@ -93,7 +96,8 @@ func (a *App) loadSources() error {
 	if err != nil {
 		return nil
 	}
-	reordered_sources, err := reorderSources(filtered_sources)
+	corroborated_sources := hideUncorroboratedLowTrust(filtered_sources)
+	reordered_sources, err := reorderSources(corroborated_sources)
 	if err != nil {
 		return nil
 	}
@@ -144,6 +148,11 @@ func (a *App) draw() {
 		return
 	}
 
+	if a.mode == "timeline" {
+		a.drawTimelineView(width, height, style)
+		return
+	}
+
 	startIdx := a.currentPage * a.itemsPerPage
 	endIdx := startIdx + a.itemsPerPage
 	if endIdx > len(a.sources) {
@@ -297,7 +306,7 @@ func (a *App) drawDetailView(width, height int, style, summaryStyle, importanceS
 	if err == nil {
 		host = parsedURL.Host
 	}
-	metaInfo := fmt.Sprintf("Source: %s | Date: %s", host, source.Date.Format("2006-01-02 15:04"))
+	metaInfo := fmt.Sprintf("Source: %s (%s trust) | Date: %s", host, source.Tier, source.Date.Format("2006-01-02 15:04"))
 	lineIdx = drawText(a.screen, 0, lineIdx, width, style, metaInfo)
 	lineIdx++
 	lineIdx++
@@ -326,7 +335,7 @@ func (a *App) drawDetailView(width, height int, style, summaryStyle, importanceS
 	lineIdx = drawText(a.screen, 0, lineIdx, width, style, source.Link)
 
 	// Help text at bottom
-	helpText := "ESC/Backspace: Back to list | O: Open in Browser | M: Toggle mark | S: Save | W: Web Search | Q: Quit"
+	helpText := "ESC/Backspace: Back to list | O: Open in Browser | M: Toggle mark | S: Save | W: Web Search | T: Story Timeline | Q: Quit"
 	if height > 0 {
 		drawText(a.screen, 0, height-1, width, style, helpText)
 	}
@@ -391,6 +400,7 @@ func (a *App) drawHelpView() {
 		"M: Toggle mark",
 		"S: Save",
 		"W: Web Search",
+		"T: Story Timeline",
 		"C: Mark cluster centrals as processed",
 		"D: Mark items before date as processed",
 		"Q: Quit",
@@ -526,6 +536,9 @@ func (a *App) run() error {
 					a.searchInstance = nil
 				} else if a.mode == "help" {
 					a.mode = "main"
+				} else if a.mode == "timeline" {
+					a.mode = a.previousMode
+					a.timeline = nil
 				} else {
 					if a.confirmQuit() {
 						a.waitgroup.Wait()
@@ -539,6 +552,9 @@ func (a *App) run() error {
 				} else if a.mode == "search" {
 					a.mode = "main"
 					a.searchInstance = nil
+				} else if a.mode == "timeline" {
+					a.mode = a.previousMode
+					a.timeline = nil
 				}
 			case tcell.KeyRight:
 				if a.mode == "main" {
@@ -798,6 +814,14 @@ func (a *App) run() error {
 							}
 						}
 					}
+				case 't', 'T':
+					if len(a.sources) > 0 && (a.mode == "main" || a.mode == "detail") {
+						idx := a.selectedIdx
+						if a.mode == "detail" {
+							idx = a.detailIdx
+						}
+						a.showTimeline(idx)
+					}
 				case 'h', 'H':
 					if a.mode == "main" {
 						a.mode = "help"
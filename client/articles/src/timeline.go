@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jackc/pgx/v4"
+)
+
+type StoryEvent struct {
+	Title          string
+	Link           string
+	Date           time.Time
+	Delta          string
+	DeltaReasoning string
+}
+
+type StoryTimeline struct {
+	Name        string
+	LatestState string
+	Events      []StoryEvent // oldest first
+}
+
+// Find the story a source was linked to on the server, and get its whole timeline
+func loadStoryTimeline(link string) (*StoryTimeline, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, os.Getenv("DATABASE_POOL_URL"))
+	if err != nil {
+		log.Printf("failed to connect to database: %v", err)
+		return nil, fmt.Errorf("database connection error: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	var story_id int
+	timeline := StoryTimeline{}
+	err = conn.QueryRow(ctx, `
+		SELECT s.id, s.name, COALESCE(s.latest_state, '')
+		FROM stories s JOIN story_events e ON e.story_id = s.id
+		WHERE e.link = $1
+		ORDER BY s.updated_at DESC
+		LIMIT 1`, link).Scan(&story_id, &timeline.Name, &timeline.LatestState)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("item is not part of any story")
+	} else if err != nil {
+		log.Printf("failed to query story: %v", err)
+		return nil, fmt.Errorf("database query error: %v", err)
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT title, link, date, delta, COALESCE(delta_reasoning, '')
+		FROM story_events
+		WHERE story_id = $1
+		ORDER BY date ASC, id ASC`, story_id)
+	if err != nil {
+		log.Printf("failed to query story events: %v", err)
+		return nil, fmt.Errorf("database query error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e StoryEvent
+		if err := rows.Scan(&e.Title, &e.Link, &e.Date, &e.Delta, &e.DeltaReasoning); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		timeline.Events = append(timeline.Events, e)
+	}
+	return &timeline, rows.Err()
+}
+
+func (a *App) showTimeline(idx int) {
+	timeline, err := loadStoryTimeline(a.sources[idx].Link)
+	if err != nil {
+		a.statusMessage = fmt.Sprintf("Timeline: %v", err)
+		go func() {
+			time.Sleep(2 * time.Second)
+			a.statusMessage = ""
+			a.screen.Sync()
+		}()
+		return
+	}
+	a.timeline = timeline
+	a.previousMode = a.mode
+	a.mode = "timeline"
+}
+
+func deltaStyle(delta string, style tcell.Style) tcell.Style {
+	switch delta {
+	case "escalation":
+		return style.Foreground(tcell.ColorRed)
+	case "de-escalation":
+		return style.Foreground(tcell.ColorGreen)
+	default:
+		return style.Foreground(tcell.Color248)
+	}
+}
+
+func deltaMark(delta string) string {
+	switch delta {
+	case "escalation":
+		return "[+]"
+	case "de-escalation":
+		return "[-]"
+	default:
+		return "[=]"
+	}
+}
+
+func (a *App) drawTimelineView(width, height int, style tcell.Style) {
+	if a.timeline == nil {
+		return
+	}
+
+	lineIdx := 0
+	lineIdx = drawText(a.screen, 0, lineIdx, width, style.Bold(true), "Story: "+a.timeline.Name)
+	lineIdx++
+	if a.timeline.LatestState != "" {
+		lineIdx = drawText(a.screen, 0, lineIdx, width, style.Foreground(tcell.Color248), a.timeline.LatestState)
+		lineIdx++
+	}
+	lineIdx = drawText(a.screen, 0, lineIdx, width, style, "----------------------------------------")
+	lineIdx++
+
+	// Show the most recent events that fit on screen, oldest first, so the latest delta is always at the bottom
+	events := a.timeline.Events
+	maxEvents := (height - lineIdx - 4) / 2
+	if maxEvents < 1 {
+		maxEvents = 1
+	}
+	if len(events) > maxEvents {
+		events = events[len(events)-maxEvents:]
+	}
+
+	for i, e := range events {
+		isLatest := i == len(events)-1
+		currentStyle := deltaStyle(e.Delta, style)
+		if isLatest {
+			currentStyle = currentStyle.Bold(true).Reverse(true)
+		}
+		text := fmt.Sprintf("%s %s %s", e.Date.Format("2006-01-02"), deltaMark(e.Delta), e.Title)
+		lineIdx = drawText(a.screen, 0, lineIdx, width, currentStyle, text)
+		lineIdx++
+		if isLatest && e.DeltaReasoning != "" {
+			lineIdx = drawText(a.screen, 4, lineIdx, width-4, deltaStyle(e.Delta, style), e.DeltaReasoning)
+			lineIdx++
+		}
+		if lineIdx >= height-1 {
+			break
+		}
+	}
+
+	helpText := fmt.Sprintf("%d events | [+] escalation [-] de-escalation [=] no change | ESC/Backspace: Back", len(a.timeline.Events))
+	if height > 0 {
+		drawText(a.screen, 0, height-1, width, style, helpText)
+	}
+
+	a.screen.Show()
+}
@@ -20,6 +20,8 @@ type Source struct {
 	RelevantPerHumanCheck string
 	ClusterID             int    // New field for cluster assignment
 	IsClusterCentral      bool   // New field to mark central points vs outliers
+	Tier                  string // trust tier of the site, from the server's lib/domains: "low", "normal" or "high"
+	Corroborated          bool   // another outlet is in the same story
 }
 
 var RELEVANT_PER_HUMAN_CHECK_NO = "no"
@@ -37,13 +39,15 @@ type App struct {
 	failureMark    bool
 	waitgroup      sync.WaitGroup
 	statusMessage  string
-	mode           string // "main", "detail", "help", "search"
+	mode           string // "main", "detail", "help", "search", "timeline"
+	previousMode   string // Mode to go back to when leaving the timeline
 	detailIdx      int   // Index of item being viewed in detail
 	searchInstance *search.Search // Search instance for web search mode
 	searchOriginIdx int   // Index of the source that initiated the search
 	embeddings     [][]float64 // Store embeddings for distance calculations
 	clusters       []Cluster   // Store clusters with centroids
 	clusterStyles  []tcell.Style // Store cluster-specific colors
+	timeline       *StoryTimeline // Story timeline being viewed
 }
 
 type Topic struct {
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"net/url"
+	"sort"
 	"strings"
+
+	"golang.org/x/net/publicsuffix"
 )
 
 func padStringWithWhitespace(s string, n int) string {
@@ -33,6 +37,36 @@ func cleanTitle(s string) string {
 }
 
 
+// getShortHost gets the registrable domain of a link, e.g. bbc.co.uk
+func getShortHost(link string) string {
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	host, err := publicsuffix.EffectiveTLDPlusOne(parsedURL.Hostname())
+	if err != nil {
+		return parsedURL.Hostname()
+	}
+	return host
+}
+
+func tierRank(tier string) int {
+	switch tier {
+	case "high":
+		return 2
+	case "low":
+		return 0
+	}
+	return 1
+}
+
+// sortByTier puts high-trust sites first, keeping the order otherwise
+func sortByTier(sources []Source) {
+	sort.SliceStable(sources, func(i, j int) bool {
+		return tierRank(sources[i].Tier) > tierRank(sources[j].Tier)
+	})
+}
+
 func stripHTML(s string) string {
 	var result strings.Builder
 	var inTag bool
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+/* Sources which follow a list of feeds, accounts or pages keep it in a json file next to them,
+with one entry per item and a "disabled" field to turn one off without deleting it. */
+
+// LoadList reads the json list in file and returns the entries which aren't disabled.
+// validate checks an entry, filling in any defaults, and returns what must be unique about it, like its url.
+// Anything malformed is an error, as is a list with nothing enabled, so that a source fails as soon as it starts.
+// noun names an entry in errors, e.g. "feed".
+func LoadList[T any](file string, noun string, validate func(entry *T) ([]string, error), disabled func(entry T) bool) ([]T, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var entries []T
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", file, err)
+	}
+
+	var enabled []T
+	seen := map[string]int{}
+	for i := range entries {
+		keys, err := validate(&entries[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s #%d in %s: %w", noun, i+1, file, err)
+		}
+		for _, key := range keys {
+			if j, ok := seen[key]; ok {
+				return nil, fmt.Errorf("%s appears twice in %s, in %ss #%d and #%d", key, file, noun, j+1, i+1)
+			}
+			seen[key] = i
+		}
+		if !disabled(entries[i]) {
+			enabled = append(enabled, entries[i])
+		}
+	}
+	if len(enabled) == 0 {
+		return nil, fmt.Errorf("%s has no enabled %ss", file, noun)
+	}
+	return enabled, nil
+}
+
+// IsURL is true for absolute http and https urls
+func IsURL(link string) bool {
+	parsed, err := url.Parse(link)
+	return err == nil && (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
@@ -0,0 +1,164 @@
+package domains
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+/* How much we trust each site. Sites are matched by eTLD+1 (e.g. "bbc.co.uk" for "www.bbc.co.uk/news"),
+by their full host, or by a suffix starting with a dot, like ".gov". The most specific match wins.
+The policy is in domains.json, and can be changed without recompiling by pointing DOMAIN_POLICY_FILE
+to a file in the same format, which is re-read when it changes. */
+
+type Tier string
+
+const (
+	TierBlock  Tier = "block"  // never saved
+	TierLow    Tier = "low"    // only shown once a second outlet corroborates it
+	TierNormal Tier = "normal" // anything not in the policy
+	TierHigh   Tier = "high"   // wire services, governments and international organizations
+)
+
+// Rank orders tiers, for sorting
+func (t Tier) Rank() int {
+	switch t {
+	case TierBlock:
+		return -1
+	case TierLow:
+		return 0
+	case TierHigh:
+		return 2
+	}
+	return 1
+}
+
+// Policy maps each tier to its domains
+type Policy map[Tier][]string
+
+//go:embed domains.json
+var default_policy []byte
+
+const policyCheckInterval = time.Minute
+
+var (
+	tiers          map[string]Tier // domain or ".suffix" to tier
+	policy_path    string
+	policy_mtime   time.Time
+	policy_checked time.Time
+	policy_lock    sync.Mutex
+)
+
+func init() {
+	parsed, err := parsePolicy(default_policy)
+	if err != nil {
+		log.Fatalf("Error parsing embedded domain policy: %v", err)
+	}
+	tiers = parsed
+}
+
+func parsePolicy(data []byte) (map[string]Tier, error) {
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, err
+	}
+	parsed := map[string]Tier{}
+	for tier, domains := range policy {
+		if tier != TierBlock && tier != TierLow && tier != TierNormal && tier != TierHigh {
+			return nil, fmt.Errorf("unknown tier %q", tier)
+		}
+		for _, domain := range domains {
+			domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+			if previous, ok := parsed[domain]; ok && previous != tier {
+				return nil, fmt.Errorf("%s is both %s and %s", domain, previous, tier)
+			}
+			parsed[domain] = tier
+		}
+	}
+	return parsed, nil
+}
+
+// reloadIfChanged re-reads DOMAIN_POLICY_FILE if it has changed. Must be called with policy_lock held.
+func reloadIfChanged() {
+	path := os.Getenv("DOMAIN_POLICY_FILE")
+	if path == "" || path == policy_path && time.Since(policy_checked) < policyCheckInterval {
+		return
+	}
+	policy_checked = time.Now()
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Printf("Error reading domain policy %s, keeping the current one: %v", path, err)
+		return
+	}
+	if path == policy_path && info.ModTime().Equal(policy_mtime) {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Error reading domain policy %s, keeping the current one: %v", path, err)
+		return
+	}
+	parsed, err := parsePolicy(data)
+	if err != nil {
+		log.Printf("Error parsing domain policy %s, keeping the current one: %v", path, err)
+		return
+	}
+	tiers, policy_path, policy_mtime = parsed, path, info.ModTime()
+	log.Printf("Loaded domain policy from %s", path)
+}
+
+// Domain is the registrable domain of a link, e.g. "bbc.co.uk"; it is the host itself if it has no public suffix
+func Domain(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain
+	}
+	return host
+}
+
+// TierFor finds the tier of a link's site
+func TierFor(link string) Tier {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return TierNormal
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	if host == "" {
+		return TierNormal
+	}
+
+	policy_lock.Lock()
+	defer policy_lock.Unlock()
+	reloadIfChanged()
+
+	if tier, ok := tiers[host]; ok {
+		return tier
+	}
+	if tier, ok := tiers[Domain(link)]; ok {
+		return tier
+	}
+	// Longest suffix first, so that e.g. ".gov.uk" wins over ".uk"
+	for suffix := host; ; {
+		i := strings.Index(suffix[1:], ".")
+		if i < 0 {
+			break
+		}
+		suffix = suffix[i+1:]
+		if tier, ok := tiers[suffix]; ok {
+			return tier
+		}
+	}
+	return TierNormal
+}
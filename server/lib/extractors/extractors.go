@@ -0,0 +1,207 @@
+package extractors
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feeds"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Rule says where the title, body and date of an article are on one site, as CSS selectors.
+// Sites without a rule fall back to readability.
+type Rule struct {
+	Name     string   `json:"name"`
+	Domains  []string `json:"domains"`             // also matches subdomains, e.g. "gmw.cn" matches "mil.gmw.cn"
+	Title    string   `json:"title,omitempty"`     // if empty or not found, the page's <title>
+	Body     string   `json:"body"`                // comma separated alternatives are tried in order, and the first match is used
+	Date     string   `json:"date,omitempty"`      // element whose date_attr (or else text) contains the publication date
+	DateAttr string   `json:"date_attr,omitempty"` // e.g. "content" for <meta property="article:published_time">
+	Remove   []string `json:"remove,omitempty"`    // boilerplate inside the body: share buttons, related links, etc.
+}
+
+type Extracted struct {
+	Rule    string
+	Title   string
+	Body    string // plain text, one paragraph per line
+	Date    time.Time
+	HasDate bool
+}
+
+//go:embed rules.json
+var default_rules []byte
+
+var (
+	rules      []Rule
+	rules_lock sync.RWMutex
+)
+
+func init() {
+	parsed, err := parseRules(default_rules)
+	if err != nil {
+		log.Fatalf("Error parsing embedded extractor rules: %v", err)
+	}
+	rules = parsed
+}
+
+func parseRules(data []byte) ([]Rule, error) {
+	var parsed []Rule
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+	for i, rule := range parsed {
+		if len(rule.Domains) == 0 || rule.Body == "" {
+			return nil, fmt.Errorf("rule #%d (%q) needs at least a domain and a body selector", i+1, rule.Name)
+		}
+	}
+	return parsed, nil
+}
+
+// LoadRules replaces the embedded rules with those in a file, e.g. to try out a new rule
+func LoadRules(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	parsed, err := parseRules(data)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	rules_lock.Lock()
+	rules = parsed
+	rules_lock.Unlock()
+	return nil
+}
+
+// RuleFor finds the rule for a link's domain
+func RuleFor(link string) (Rule, bool) {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return Rule{}, false
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+
+	rules_lock.RLock()
+	defer rules_lock.RUnlock()
+	for _, rule := range rules {
+		for _, domain := range rule.Domains {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return rule, true
+			}
+		}
+	}
+	return Rule{}, false
+}
+
+// paragraphs gets the text of a selection, one paragraph per line
+func paragraphs(selection *goquery.Selection) string {
+	var lines []string
+	blocks := selection.Find("p, h2, h3, h4, li, blockquote")
+	if blocks.Length() == 0 {
+		blocks = selection
+	}
+	blocks.Each(func(_ int, block *goquery.Selection) {
+		// Avoid repeating the text of e.g. a <p> inside an <li>
+		if block.ParentsFiltered("p, li, blockquote").Length() > 0 {
+			return
+		}
+		if line := strings.Join(strings.Fields(block.Text()), " "); line != "" {
+			lines = append(lines, line)
+		}
+	})
+	return strings.Join(lines, "\n")
+}
+
+var date_pattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}([ T]\d{2}:\d{2}(:\d{2})?)?`)
+
+// parseDate reads a date from e.g. "来源：光明网 2025-02-10 08:36" or "February 10, 2025"
+func parseDate(s string) (time.Time, bool) {
+	s = strings.Join(strings.Fields(s), " ")
+	if t, err := feeds.ParseDate(s); err == nil {
+		return t, true
+	}
+	if match := date_pattern.FindString(s); match != "" {
+		if t, err := feeds.ParseDate(strings.Replace(match, "T", " ", 1)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// first returns the first element matching the first alternative in selector that matches anything.
+// (goquery would instead return the first match of any alternative, in document order.)
+// Alternatives are split on commas, so selectors can't have commas inside e.g. attribute values.
+func first(doc *goquery.Document, selector string) *goquery.Selection {
+	for _, alternative := range strings.Split(selector, ",") {
+		if found := doc.Find(strings.TrimSpace(alternative)); found.Length() > 0 {
+			return found.First()
+		}
+	}
+	return doc.Find(selector).First()
+}
+
+// Extract applies a rule to a page
+func Extract(html []byte, rule Rule) (Extracted, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		return Extracted{}, err
+	}
+	extracted := Extracted{Rule: rule.Name}
+
+	if rule.Title != "" {
+		extracted.Title = strings.TrimSpace(first(doc, rule.Title).Text())
+	}
+	if extracted.Title == "" {
+		extracted.Title = strings.TrimSpace(doc.Find("title").First().Text())
+	}
+
+	if rule.Date != "" {
+		date_element := first(doc, rule.Date)
+		date_text, has_attr := date_element.Attr(rule.DateAttr)
+		if rule.DateAttr == "" || !has_attr {
+			date_text = date_element.Text()
+		}
+		extracted.Date, extracted.HasDate = parseDate(date_text)
+	}
+
+	body := first(doc, rule.Body)
+	if body.Length() == 0 {
+		return extracted, fmt.Errorf("rule %q: body selector %q matched nothing", rule.Name, rule.Body)
+	}
+	body.Find("script, style, noscript").Remove()
+	body.Find("br").ReplaceWithHtml(" ")
+	for _, selector := range rule.Remove {
+		body.Find(selector).Remove()
+	}
+	extracted.Body = paragraphs(body)
+	if extracted.Body == "" {
+		return extracted, fmt.Errorf("rule %q: body is empty", rule.Name)
+	}
+	return extracted, nil
+}
+
+var ErrNoRule = errors.New("no extractor rule for this domain")
+
+// ExtractURL fetches a page and applies the rule for its domain. It returns ErrNoRule if there is none.
+func ExtractURL(link string) (Extracted, error) {
+	rule, ok := RuleFor(link)
+	if !ok {
+		return Extracted{}, ErrNoRule
+	}
+	html, err := web.Get(link)
+	if err != nil {
+		return Extracted{}, err
+	}
+	return Extract(html, rule)
+}
@@ -0,0 +1,327 @@
+package feeds
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+// Item is one entry of an RSS 2.0, Atom, RDF (RSS 1.0) or JSON Feed feed
+type Item struct {
+	Title   string
+	Link    string
+	Summary string
+	Date    time.Time
+	HasDate bool // false if the feed didn't give a date we could parse; Date is then the time we fetched it
+}
+
+/* RSS 2.0 and RDF (RSS 1.0) share item fields; RDF puts items next to the channel rather than inside it */
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	DCDate      string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Updated     string `xml:"updated"`
+}
+
+type rssFeed struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"` // RDF
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+type atomFeed struct {
+	Entries []atomEntry `xml:"entry"`
+}
+
+type jsonFeed struct {
+	Items []struct {
+		Title         string `json:"title"`
+		URL           string `json:"url"`
+		ExternalURL   string `json:"external_url"`
+		Summary       string `json:"summary"`
+		ContentText   string `json:"content_text"`
+		DatePublished string `json:"date_published"`
+		DateModified  string `json:"date_modified"`
+	} `json:"items"`
+}
+
+// Feeds in the wild use many more date formats than the specs allow
+var dateFormats = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	time.RFC3339Nano,
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC850,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 02 Jan 2006 15:04:05 -07:00",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 02 Jan 2006 15:04:05",
+	"02 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"January 2, 2006",
+}
+
+// ParseDate tries every date format we have seen in feeds
+func ParseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, errors.New("empty date")
+	}
+	// e.g. "Tue, 10 Jun 2025 14:00:00 GMT+0000" or "Tue, 10 Jun 2025 14:00:00 UT"
+	s = strings.Replace(s, "GMT+0000", "+0000", 1)
+	if strings.HasSuffix(s, " UT") {
+		s += "C"
+	}
+	for _, format := range dateFormats {
+		if t, err := time.Parse(format, s); err == nil {
+			return t, nil
+		}
+	}
+	// Some feeds put the weekday in a different language, or get it wrong; try again without it
+	if _, rest, found := strings.Cut(s, ", "); found {
+		for _, format := range dateFormats {
+			if _, format_rest, has_weekday := strings.Cut(format, ", "); has_weekday {
+				if t, err := time.Parse(format_rest, rest); err == nil {
+					return t, nil
+				}
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format: %q", s)
+}
+
+func newItem(title string, link string, summary string, dates ...string) Item {
+	item := Item{
+		Title:   strings.TrimSpace(html.UnescapeString(title)),
+		Link:    strings.TrimSpace(link),
+		Summary: strings.TrimSpace(summary),
+		Date:    time.Now(),
+	}
+	for _, date := range dates {
+		if t, err := ParseDate(date); err == nil {
+			item.Date = t
+			item.HasDate = true
+			break
+		}
+	}
+	return item
+}
+
+func parseJSONFeed(data []byte) ([]Item, error) {
+	var feed jsonFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("parsing JSON feed: %w", err)
+	}
+	var items []Item
+	for _, i := range feed.Items {
+		link := i.URL
+		if link == "" {
+			link = i.ExternalURL
+		}
+		summary := i.Summary
+		if summary == "" {
+			summary = i.ContentText
+		}
+		items = append(items, newItem(i.Title, link, summary, i.DatePublished, i.DateModified))
+	}
+	return items, nil
+}
+
+func atomEntryLink(entry atomEntry) string {
+	for _, link := range entry.Links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	if len(entry.Links) > 0 {
+		return entry.Links[0].Href
+	}
+	return ""
+}
+
+// rootElement finds the name of the first element of an xml document
+func rootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("no root element: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func unmarshalXML(data []byte, v any) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity // e.g. &nbsp;, which isn't valid xml but is common in feeds
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// Good enough for the odd feed that declares e.g. ISO-8859-1 but is really ascii
+		return input, nil
+	}
+	return decoder.Decode(v)
+}
+
+// Parse detects the kind of feed and returns its items
+func Parse(data []byte) ([]Item, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return parseJSONFeed(trimmed)
+	}
+
+	root, err := rootElement(trimmed)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	switch root {
+	case "rss", "RDF":
+		var feed rssFeed
+		if err := unmarshalXML(trimmed, &feed); err != nil {
+			return nil, fmt.Errorf("parsing %s feed: %w", root, err)
+		}
+		for _, i := range append(feed.Channel.Items, feed.Items...) {
+			link := i.Link
+			if link == "" && strings.HasPrefix(i.GUID, "http") {
+				link = i.GUID
+			}
+			items = append(items, newItem(i.Title, link, i.Description, i.PubDate, i.DCDate, i.Updated))
+		}
+	case "feed":
+		var feed atomFeed
+		if err := unmarshalXML(trimmed, &feed); err != nil {
+			return nil, fmt.Errorf("parsing atom feed: %w", err)
+		}
+		for _, entry := range feed.Entries {
+			summary := entry.Summary
+			if summary == "" {
+				summary = entry.Content
+			}
+			items = append(items, newItem(entry.Title, atomEntryLink(entry), summary, entry.Published, entry.Updated))
+		}
+	default:
+		return nil, fmt.Errorf("unknown feed type, root element is <%s>", root)
+	}
+	return items, nil
+}
+
+/* Conditional GET: remember each feed's ETag and Last-Modified, so unchanged feeds cost a 304 */
+type validators struct {
+	etag          string
+	last_modified string
+}
+
+var (
+	seen_validators = map[string]validators{}
+	validators_lock sync.Mutex
+)
+
+// Fetch gets and parses a feed. If the feed hasn't changed since the last Fetch, it returns no items.
+func Fetch(url string) ([]Item, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, */*;q=0.8")
+
+	validators_lock.Lock()
+	v := seen_validators[url]
+	validators_lock.Unlock()
+	if v.etag != "" {
+		req.Header.Set("If-None-Match", v.etag)
+	}
+	if v.last_modified != "" {
+		req.Header.Set("If-Modified-Since", v.last_modified)
+	}
+
+	resp, err := web.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		log.Printf("Feed not modified: %s", url)
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading feed %s: %v", url, err)
+		return nil, err
+	}
+	items, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only remember validators once we have successfully parsed the feed
+	validators_lock.Lock()
+	seen_validators[url] = validators{etag: resp.Header.Get("ETag"), last_modified: resp.Header.Get("Last-Modified")}
+	validators_lock.Unlock()
+	return items, nil
+}
+
+// ToSources converts feed items into sources with the given origin
+func ToSources(items []Item, origin string) []types.Source {
+	var sources []types.Source
+	for _, item := range items {
+		if item.Link == "" {
+			continue
+		}
+		sources = append(sources, types.Source{Title: item.Title, Link: item.Link, Date: item.Date, Origin: origin})
+	}
+	return sources
+}
+
+// FetchSources is Fetch followed by ToSources
+func FetchSources(url string, origin string) ([]types.Source, error) {
+	items, err := Fetch(url)
+	if err != nil {
+		return nil, err
+	}
+	return ToSources(items, origin), nil
+}
@@ -0,0 +1,102 @@
+package filters
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// knownCount turns the -1 the llm uses for unknown counts into nil
+func knownCount(n int) *int {
+	if n < 0 {
+		return nil
+	}
+	return &n
+}
+
+// knownEventType turns an event type which isn't one of llm.EventTypes into "", so that mergeEvent can fill it in
+func knownEventType(event_type string) string {
+	event_type = strings.ToLower(strings.TrimSpace(event_type))
+	if !slices.Contains(llm.EventTypes, event_type) {
+		if event_type != "" {
+			log.Printf("Unknown event type %q, dropping it", event_type)
+		}
+		return ""
+	}
+	return event_type
+}
+
+func upperAll(xs []string) []string {
+	var result []string
+	for _, x := range xs {
+		if x = strings.ToUpper(strings.TrimSpace(x)); x != "" {
+			result = append(result, x)
+		}
+	}
+	return result
+}
+
+// mergeEvent fills the fields the llm couldn't find with those a source already knew about
+func mergeEvent(extracted types.StructuredEvent, prefilled *types.StructuredEvent) types.StructuredEvent {
+	if prefilled == nil {
+		return extracted
+	}
+	if len(extracted.Countries) == 0 {
+		extracted.Countries = prefilled.Countries
+	}
+	if len(extracted.Actors) == 0 {
+		extracted.Actors = prefilled.Actors
+	}
+	if extracted.EventType == "" {
+		extracted.EventType = prefilled.EventType
+	}
+	if extracted.Killed == nil {
+		extracted.Killed = prefilled.Killed
+	}
+	if extracted.Wounded == nil {
+		extracted.Wounded = prefilled.Wounded
+	}
+	if extracted.Affected == nil {
+		extracted.Affected = prefilled.Affected
+	}
+	if extracted.Pathogen == "" {
+		extracted.Pathogen = prefilled.Pathogen
+	}
+	if extracted.WeaponSystem == "" {
+		extracted.WeaponSystem = prefilled.WeaponSystem
+	}
+	return extracted
+}
+
+// ExtractEventFilter adds structured fields (countries, actors, casualty counts, ...) to a kept source.
+// It never filters a source out.
+func ExtractEventFilter(openrouter_key string) types.Filter {
+	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		event_snippet := "# " + source.Title + "\n\n" + source.Summary
+		box, err := llm.ExtractEvent(event_snippet, openrouter_key)
+		if err != nil || box == nil {
+			log.Printf("Could not extract structured event: %v", err)
+			return source, true
+		}
+
+		extracted := types.StructuredEvent{
+			Countries:    upperAll(box.Countries),
+			Actors:       box.Actors,
+			EventType:    knownEventType(box.EventType),
+			Killed:       knownCount(box.Killed),
+			Wounded:      knownCount(box.Wounded),
+			Affected:     knownCount(box.Affected),
+			Pathogen:     box.Pathogen,
+			WeaponSystem: box.WeaponSystem,
+		}
+		merged := mergeEvent(extracted, source.Event)
+		source.Event = &merged
+
+		log.Printf("Event: %s in %v", merged.EventType, merged.Countries)
+		return source, true
+	}
+	return filter
+}
@@ -3,8 +3,6 @@ package filters
 import (
 	"context"
 	"log"
-	"net/url"
-	"slices"
 	"strings"
 	"time"
 
@@ -51,20 +49,7 @@ func IsDupe(source types.Source, database_url string) bool {
 }
 
 func IsGoodHost(source types.Source) bool {
-	parsedURL, err := url.Parse(source.Link)
-	if err != nil {
-		log.Printf("Error parsing link: %v", err)
-		return false
-	}
-	skippable_hosts := []string{"www.washingtonpost.com", "www.youtube.com", "www.naturalnews.com", "facebook.com", "m.facebook.com", "www.bignewsnetwork.com"}
-	is_bad_host := slices.Contains(skippable_hosts, parsedURL.Host)
-	if is_bad_host {
-		log.Printf("Article is from a bad host")
-	} else {
-		log.Printf("Article is from a good host")
-	}
-
-	return !is_bad_host
+	return isGoodHost(source.Link)
 }
 
 func CleanTitle0(s string, endingMarker string) string {
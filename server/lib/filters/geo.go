@@ -0,0 +1,34 @@
+package filters
+
+import (
+	"log"
+
+	"git.nunosempere.com/NunoSempere/news/lib/geo"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// GeotagFilter resolves the places a kept source talks about against the offline gazetteer in lib/geo.
+// Locations given by the source itself (e.g. by GDELT) are kept; countries found by ExtractEventFilter are used as a fallback.
+// It never filters a source out.
+func GeotagFilter() types.Filter {
+	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		if len(source.Locations) > 0 {
+			return source, true
+		}
+
+		source.Locations = geo.Resolve(source.Title + "\n" + source.Summary)
+		if len(source.Locations) == 0 && source.Event != nil {
+			for _, code := range source.Event.Countries {
+				if location, ok := geo.Country(code); ok {
+					source.Locations = append(source.Locations, location)
+				}
+			}
+		}
+
+		if len(source.Locations) > 0 {
+			log.Printf("Locations: %v", geo.CountryCodes(source.Locations))
+		}
+		return source, true
+	}
+	return filter
+}
@@ -3,11 +3,12 @@ package filters
 import (
 	"context"
 	"log"
-	"net/url"
-	"slices"
 
+	"git.nunosempere.com/NunoSempere/news/lib/domains"
+	"git.nunosempere.com/NunoSempere/news/lib/geo"
 	"git.nunosempere.com/NunoSempere/news/lib/llm"
 	"git.nunosempere.com/NunoSempere/news/lib/readability"
+	"git.nunosempere.com/NunoSempere/news/lib/rules"
 	"git.nunosempere.com/NunoSempere/news/lib/types"
 	"github.com/jackc/pgx/v5"
 
@@ -29,6 +30,23 @@ func IsFreshFilter() types.Filter {
 	return filter
 }
 
+// MaxAgeFilter drops sources older than a number of hours, for sources which only matter while they are news, like alerts.
+// With 0 hours it is IsFreshFilter.
+func MaxAgeFilter(hours int) types.Filter {
+	if hours == 0 {
+		return IsFreshFilter()
+	}
+	max_age := time.Duration(hours) * time.Hour
+	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		if age := time.Since(source.Date); age > max_age {
+			log.Printf("Filtered because: older than %dh (%v)", hours, age.Round(time.Minute))
+			return source, false
+		}
+		return source, true
+	}
+	return filter
+}
+
 func isDupeTitleOrLink(database_url string, title string, link string) bool {
 	conn, err := pgx.Connect(context.Background(), database_url)
 	if err != nil {
@@ -64,25 +82,21 @@ func IsDupeFilter(database_url string) types.Filter {
 	return filter
 }
 
+// isGoodHost is false for sites blocked by the domain policy in lib/domains
 func isGoodHost(link string) bool {
-	parsedURL, err := url.Parse(link)
-	if err != nil {
-		log.Printf("Error parsing link: %v", err)
+	tier := domains.TierFor(link)
+	if tier == domains.TierBlock {
+		log.Printf("Article is from a blocked host")
 		return false
 	}
-	skippable_hosts := []string{"www.washingtonpost.com", "www.youtube.com", "www.naturalnews.com", "facebook.com", "m.facebook.com", "www.bignewsnetwork.com"}
-	is_bad_host := slices.Contains(skippable_hosts, parsedURL.Host)
-	if is_bad_host {
-		log.Printf("Article is from a bad host")
-	} else {
-		log.Printf("Article is from a good host")
-	}
-
-	return !is_bad_host
+	log.Printf("Article is from a good host (%s trust)", tier)
+	return true
 }
 
+// IsGoodHostFilter drops blocked sites, and notes the trust tier of the others
 func IsGoodHostFilter() types.Filter {
 	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		source.Tier = string(domains.TierFor(source.Link))
 		return source, isGoodHost(source.Link)
 	}
 	return filter
@@ -109,38 +123,77 @@ func ExtractBetterTitle() types.Filter {
 	return filter
 }
 
+// ExtractSummaryFilter summarizes a source's content, or else the article at its link
 func ExtractSummaryFilter(openrouter_key string) types.Filter {
 	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
-		content, err := readability.GetArticleContent(source.Link)
-		if err != nil {
-			log.Printf("Filtered because: Error getting article content: %v", err)
-			return source, false
-		}
-
-		summary, err := llm.Summarize(content, openrouter_key)
-		if err != nil {
-			log.Printf("Filtered because: Error summarizing: %v", err)
-			return source, false
-		}
-		source.Summary = summary
-		return source, true
+		return ExtractContentAndSummarize(source, openrouter_key)
 	}
 	return filter
 }
 
+// ImportanceProfileFor picks the importance profile for a source from the regions and topics it mentions
+func ImportanceProfileFor(source types.ExpandedSource) string {
+	country_codes := geo.CountryCodes(source.Locations)
+	if source.Event != nil {
+		country_codes = append(country_codes, source.Event.Countries...)
+	}
+	return llm.DetectProfile(source.Title+"\n\n"+source.Summary, country_codes)
+}
+
+// checkImportance checks a source with an importance profile, or with the one it mentions if profile is "".
+// The note, if any, goes before the source, and what ExtractOutbreakFilter found after it.
+func checkImportance(source types.ExpandedSource, profile string, note string, openrouter_key string) (types.ExpandedSource, bool) {
+	if profile == "" {
+		profile = ImportanceProfileFor(source)
+	}
+	log.Printf("Importance profile: %s", profile)
+	existential_importance_snippet := "# " + source.Title + "\n\n" + source.Summary
+	if outbreak_note := OutbreakNote(source); outbreak_note != "" {
+		existential_importance_snippet += "\n\nExtracted from the report: " + outbreak_note
+	}
+	if note != "" {
+		existential_importance_snippet = note + "\n\n" + existential_importance_snippet
+	}
+	existential_importance_box, err := llm.CheckImportanceWithProfile(existential_importance_snippet, profile, openrouter_key)
+	if err != nil || existential_importance_box == nil {
+		log.Printf("Filtered because: could not check importance: %v", err)
+		source.Failed = true
+		return source, false
+	}
+	source.ImportanceBool = existential_importance_box.ExistentialImportanceBool
+	source.ImportanceReasoning = existential_importance_box.ExistentialImportanceReasoning
+
+	log.Printf("importance bool: %t", source.ImportanceBool)
+	return source, source.ImportanceBool
+}
+
+// CheckImportanceFilter checks importance with the profile for the regions and topics a source mentions
 func CheckImportanceFilter(openrouter_key string) types.Filter {
+	return ProfileImportanceFilter("", openrouter_key)
+}
+
+// ProfileImportanceFilter checks importance with a given profile, see lib/llm/profiles.json
+func ProfileImportanceFilter(profile string, openrouter_key string) types.Filter {
 	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
-		existential_importance_snippet := "# " + source.Title + "\n\n" + source.Summary
-		existential_importance_box, err := llm.CheckExistentialImportance(existential_importance_snippet, openrouter_key)
-		if err != nil || existential_importance_box == nil {
-			log.Printf("Filtered because: is not important")
-			return source, false
-		}
-		source.ImportanceBool = existential_importance_box.ExistentialImportanceBool
-		source.ImportanceReasoning = existential_importance_box.ExistentialImportanceReasoning
+		return checkImportance(source, profile, "", openrouter_key)
+	}
+	return filter
+}
+
+// ProfileImportanceFilterWithNote is ProfileImportanceFilter with a note for the LLM about the source,
+// like what matters in a feed, or how readers reacted to it
+func ProfileImportanceFilterWithNote(profile string, note string, openrouter_key string) types.Filter {
+	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		return checkImportance(source, profile, note, openrouter_key)
+	}
+	return filter
+}
 
-		log.Printf("importance bool: %t", source.ImportanceBool)
-		return source, source.ImportanceBool
+// WithRules runs the keyword rules in lib/rules after an importance filter,
+// which can keep or drop the source whatever the filter decided
+func WithRules(importance_filter types.Filter) types.Filter {
+	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		return rules.Apply(importance_filter(source))
 	}
 	return filter
 }
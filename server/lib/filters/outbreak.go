@@ -0,0 +1,98 @@
+package filters
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"git.nunosempere.com/NunoSempere/news/lib/geo"
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// Tags ExtractOutbreakFilter adds, so that the importance check and readers can pick out the worrying outbreaks
+const (
+	TagHumanToHumanSuspected = "human-to-human-suspected"
+	TagHumanToHumanConfirmed = "human-to-human-confirmed"
+	TagNovelPathogen         = "novel-pathogen"
+)
+
+func addTag(source types.ExpandedSource, tag string) types.ExpandedSource {
+	if !slices.Contains(source.Tags, tag) {
+		source.Tags = append(source.Tags, tag)
+	}
+	return source
+}
+
+// ExtractOutbreakFilter is ExtractEventFilter for outbreak reports: it fills in the pathogen, countries and case counts,
+// resolves the places with cases, and tags signs of human-to-human transmission and novel pathogens.
+// It runs before the importance check, so that the check can use them, and never filters a source out.
+func ExtractOutbreakFilter(openrouter_key string) types.Filter {
+	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		outbreak_snippet := "# " + source.Title + "\n\n" + source.Summary
+		box, err := llm.ExtractOutbreak(outbreak_snippet, openrouter_key)
+		if err != nil || box == nil {
+			log.Printf("Could not extract outbreak: %v", err)
+			return source, true
+		}
+
+		pathogen := box.Pathogen
+		if pathogen == "" {
+			pathogen = box.Disease
+		}
+		extracted := types.StructuredEvent{
+			Countries: upperAll(box.Countries),
+			EventType: "disease outbreak",
+			Killed:    knownCount(box.Deaths),
+			Affected:  knownCount(box.Cases),
+			Pathogen:  pathogen,
+		}
+		merged := mergeEvent(extracted, source.Event)
+		source.Event = &merged
+
+		if len(source.Locations) == 0 && len(box.Locations) > 0 {
+			source.Locations = geo.Resolve(strings.Join(box.Locations, "\n"))
+		}
+		switch box.HumanToHuman {
+		case "suspected":
+			source = addTag(source, TagHumanToHumanSuspected)
+		case "confirmed":
+			source = addTag(source, TagHumanToHumanConfirmed)
+		}
+		if box.Novel {
+			source = addTag(source, TagNovelPathogen)
+		}
+
+		log.Printf("Outbreak: %s in %v, cases: %d, deaths: %d, human-to-human: %s, novel: %t", merged.Pathogen, merged.Countries, box.Cases, box.Deaths, box.HumanToHuman, box.Novel)
+		return source, true
+	}
+	return filter
+}
+
+// OutbreakNote describes what ExtractOutbreakFilter found, for the importance check, or is empty if it found nothing
+func OutbreakNote(source types.ExpandedSource) string {
+	if source.Event == nil || source.Event.EventType != "disease outbreak" {
+		return ""
+	}
+	note := "Pathogen: " + source.Event.Pathogen
+	if len(source.Event.Countries) > 0 {
+		note += "; countries: " + strings.Join(source.Event.Countries, ", ")
+	}
+	if source.Event.Affected != nil {
+		note += fmt.Sprintf("; cases: %d", *source.Event.Affected)
+	}
+	if source.Event.Killed != nil {
+		note += fmt.Sprintf("; deaths: %d", *source.Event.Killed)
+	}
+	switch {
+	case slices.Contains(source.Tags, TagHumanToHumanConfirmed):
+		note += "; human-to-human transmission confirmed"
+	case slices.Contains(source.Tags, TagHumanToHumanSuspected):
+		note += "; human-to-human transmission suspected"
+	}
+	if slices.Contains(source.Tags, TagNovelPathogen) {
+		note += "; the pathogen is novel, or has new properties"
+	}
+	return note
+}
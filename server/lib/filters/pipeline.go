@@ -5,7 +5,9 @@ import (
 
 	"git.nunosempere.com/NunoSempere/news/lib/llm"
 	"git.nunosempere.com/NunoSempere/news/lib/readability"
+	"git.nunosempere.com/NunoSempere/news/lib/rules"
 	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
 )
 
 // ApplyFilters applies a slice of filters sequentially, stopping at the first failure
@@ -30,46 +32,53 @@ func DeprecatedStandardFilterPipeline(database_url string) []types.Filter {
 	}
 }
 
-// ExtractContentAndSummarize extracts article content and generates summary using LLM
+// Content a source brings which is shorter than this, like a single tweet, is its own summary
+const shortContentLength = 600
+
+// ExtractContentAndSummarize extracts article content and generates summary using LLM.
+// Sources which carry their own content, like tweets, are summarized from it rather than from their link.
 func ExtractContentAndSummarize(source types.ExpandedSource, openrouter_key string) (types.ExpandedSource, bool) {
-	content, err := readability.GetArticleContent(source.Link)
-	if err != nil {
-		log.Printf("Filtered because: Error getting article content: %v", err)
-		return source, false
+	content := source.Content
+	if content == "" {
+		var err error
+		content, err = readability.GetArticleContent(source.Link, source.Title)
+		if err != nil {
+			log.Printf("Filtered because: Error getting article content: %v", err)
+			source.Failed = web.IsTemporary(err)
+			return source, false
+		}
+	} else if len([]rune(content)) <= shortContentLength {
+		source.Summary = content
+		return source, true
 	}
+	return summarizeContent(source, content, openrouter_key)
+}
 
+func summarizeContent(source types.ExpandedSource, content string, openrouter_key string) (types.ExpandedSource, bool) {
 	summary, err := llm.Summarize(content, openrouter_key)
 	if err != nil {
 		log.Printf("Filtered because: Error summarizing: %v", err)
+		source.Failed = true
 		return source, false
 	}
 	source.Summary = summary
 	return source, true
 }
 
-// CheckImportance performs existential importance check using LLM
+// CheckImportance performs existential importance check using LLM, with the profile for the regions and topics the source mentions
 func CheckImportance(source types.ExpandedSource, openrouter_key string) (types.ExpandedSource, bool) {
-	existential_importance_snippet := "# " + source.Title + "\n\n" + source.Summary
-	existential_importance_box, err := llm.CheckExistentialImportance(existential_importance_snippet, openrouter_key)
-	if err != nil || existential_importance_box == nil {
-		log.Printf("Filtered because: is not important")
-		return source, false
-	}
-	source.ImportanceBool = existential_importance_box.ExistentialImportanceBool
-	source.ImportanceReasoning = existential_importance_box.ExistentialImportanceReasoning
-
-	log.Printf("importance bool: %t", source.ImportanceBool)
-	return source, source.ImportanceBool
+	return checkImportance(source, "", "", openrouter_key)
 }
 
 // StandardProcessingPipeline processes source through standard filters, content extraction, and importance check
 func DeprecatedStandardProcessingPipeline(source types.Source, openrouter_key string, database_url string) (types.ExpandedSource, bool) {
 	// Initialize expanded source
 	es := types.ExpandedSource{
-		Title:  source.Title,
-		Link:   source.Link,
-		Date:   source.Date,
-		Origin: source.Origin,
+		Title:   source.Title,
+		Link:    source.Link,
+		Date:    source.Date,
+		Origin:  source.Origin,
+		Content: source.Content,
 	}
 
 	// Apply standard filters
@@ -86,5 +95,5 @@ func DeprecatedStandardProcessingPipeline(source types.Source, openrouter_key st
 	}
 
 	// Check importance
-	return CheckImportance(es, openrouter_key)
+	return rules.Apply(CheckImportance(es, openrouter_key))
 }
@@ -0,0 +1,72 @@
+package filters
+
+import (
+	"log"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/stories"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+func storyLabel(story stories.Story) string {
+	if story.LatestState == "" {
+		return story.Name
+	}
+	return story.Name + ": " + story.LatestState
+}
+
+// TrackStoryFilter links a kept source to a long-lived story and adds it to that story's timeline.
+// It never filters a source out; failures are logged and the source passes through unchanged.
+func TrackStoryFilter(openrouter_key string, database_url string) types.Filter {
+	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		open_stories, err := stories.GetOpenStories(database_url)
+		if err != nil {
+			return source, true
+		}
+		labels := make([]string, len(open_stories))
+		for i, story := range open_stories {
+			labels[i] = storyLabel(story)
+		}
+
+		story_snippet := "# " + source.Title + "\n\n" + source.Summary
+		box, err := llm.ClassifyStoryDelta(story_snippet, labels, openrouter_key)
+		if err != nil || box == nil {
+			log.Printf("Could not classify story delta: %v", err)
+			return source, true
+		}
+		if !stories.IsValidDelta(box.Delta) {
+			log.Printf("Unknown story delta %q, using %q", box.Delta, stories.DeltaNoChange)
+			box.Delta = stories.DeltaNoChange
+		}
+
+		var story_id int
+		if box.StoryIndex >= 0 {
+			story_id = open_stories[box.StoryIndex].ID
+			log.Printf("Story: %s (%s)", open_stories[box.StoryIndex].Name, box.Delta)
+		} else {
+			if box.NewStoryName == "" {
+				log.Printf("No story name proposed, not tracking")
+				return source, true
+			}
+			story_id, err = stories.CreateStory(database_url, box.NewStoryName, box.UpdatedState)
+			if err != nil {
+				return source, true
+			}
+			log.Printf("New story: %s (%s)", box.NewStoryName, box.Delta)
+		}
+
+		event := stories.Event{
+			StoryID:        story_id,
+			Link:           source.Link,
+			Title:          source.Title,
+			Date:           source.Date,
+			Delta:          box.Delta,
+			DeltaReasoning: box.DeltaReasoning,
+		}
+		if err := stories.AddEvent(database_url, event, box.UpdatedState); err != nil {
+			log.Printf("Could not add source to story timeline: %v", err)
+		}
+		return source, true
+	}
+	return filter
+}
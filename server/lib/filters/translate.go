@@ -0,0 +1,176 @@
+package filters
+
+import (
+	"bytes"
+	"log"
+	"strings"
+
+	"git.nunosempere.com/NunoSempere/news/lib/lang"
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/readability"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"github.com/PuerkitoBio/goquery"
+)
+
+/* Sources in other languages are triaged before being translated: DetectLanguageFilter notes the language,
+GistFilter gets an English title and gist with the cheap model, the importance check runs on those
+and the original title, and only then does TranslateFilter translate the whole article with the smart model.
+Sources set OriginalTitle and OriginalText; for sources in English these filters do nothing.
+Sources which only bring a link, and so only see the language once they read the article, use SummarizeOrGist instead
+of DetectLanguageFilter and GistFilter, e.g. through ExtractSummaryOrGistFilter. */
+
+// Enough of an article to tell what it is about
+const gistInputLength = 3000
+
+// DetectLanguageFilter sets source.Language from the original text, or else from the title and summary
+func DetectLanguageFilter() types.Filter {
+	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		text := source.OriginalTitle + "\n" + source.OriginalText
+		if source.OriginalTitle == "" && source.OriginalText == "" {
+			text = source.Title + "\n" + source.Summary
+		}
+		source.Language = lang.Detect(text)
+		log.Printf("Language: %s", lang.Name(source.Language))
+		return source, true
+	}
+	return filter
+}
+
+func needsTranslation(source types.ExpandedSource) bool {
+	return !lang.IsEnglish(source.Language) && (source.OriginalTitle != "" || source.OriginalText != "")
+}
+
+// GistFilter gives a source in another language an English title, and its gist as summary,
+// keeping the original title in the summary for the importance check
+func GistFilter(openrouter_key string) types.Filter {
+	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		if !needsTranslation(source) {
+			return source, true
+		}
+		text := source.OriginalText
+		if runes := []rune(text); len(runes) > gistInputLength {
+			text = string(runes[:gistInputLength])
+		}
+		gist_box, err := llm.Gist(source.OriginalTitle, text, lang.Name(source.Language), openrouter_key)
+		if err != nil || gist_box == nil {
+			log.Printf("Filtered because: Error getting gist: %v", err)
+			source.Failed = true
+			return source, false
+		}
+		source.Title = gist_box.EnglishTitle
+		source.Summary = "Original title (" + lang.Name(source.Language) + "): " + source.OriginalTitle + "\n\n" + gist_box.Gist
+		log.Printf("Gist: %s\n%s", source.Title, gist_box.Gist)
+		return source, true
+	}
+	return filter
+}
+
+// SummarizeOrGist summarizes an article's content if it is in English. Otherwise it keeps the content as the original text,
+// for TranslateFilter, and gives the source an English title and gist as GistFilter does.
+func SummarizeOrGist(source types.ExpandedSource, content string, openrouter_key string) (types.ExpandedSource, bool) {
+	source.Language = lang.Detect(source.Title + "\n" + content)
+	if lang.IsEnglish(source.Language) {
+		return summarizeContent(source, content, openrouter_key)
+	}
+	log.Printf("Language: %s", lang.Name(source.Language))
+	source.OriginalTitle, source.OriginalText = source.Title, content
+	return GistFilter(openrouter_key)(source)
+}
+
+// ExtractSummaryOrGistFilter is ExtractSummaryFilter for sources which may link to articles in other languages
+func ExtractSummaryOrGistFilter(openrouter_key string) types.Filter {
+	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		if source.Content != "" {
+			return ExtractContentAndSummarize(source, openrouter_key)
+		}
+		content, err := readability.GetArticleContent(source.Link, source.Title)
+		if err != nil {
+			log.Printf("Filtered because: Error getting article content: %v", err)
+			source.Failed = web.IsTemporary(err)
+			return source, false
+		}
+		return SummarizeOrGist(source, content, openrouter_key)
+	}
+	return filter
+}
+
+// selectedContent returns the text of the elements matching selector, or "" if there are none
+func selectedContent(link string, selector string) (string, error) {
+	html, err := web.Get(link)
+	if err != nil {
+		return "", err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		log.Printf("Error parsing html of %s: %v", link, err)
+		return "", err
+	}
+	return strings.TrimSpace(doc.Find(selector).Text()), nil
+}
+
+// SelectorSummaryFilter is ExtractSummaryOrGistFilter for feeds whose pages readability gets wrong. It gets the content
+// with the selector if there is one, then readability, and as a last resort the fallback, e.g. the summary in the feed.
+func SelectorSummaryFilter(selector string, fallback string, openrouter_key string) types.Filter {
+	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		if source.Content != "" {
+			return ExtractContentAndSummarize(source, openrouter_key)
+		}
+
+		var content string
+		var fetch_err error
+		if selector != "" {
+			content, fetch_err = selectedContent(source.Link, selector)
+			if content == "" {
+				log.Printf("Content selector %q found nothing in %s, falling back to readability", selector, source.Link)
+			}
+		}
+		if content == "" {
+			var err error
+			content, err = readability.GetArticleContent(source.Link, source.Title)
+			if err != nil {
+				log.Printf("Readability failed for %s: %v", source.Link, err)
+				fetch_err = err
+			}
+		}
+		if strings.TrimSpace(content) == "" {
+			content = fallback
+		}
+		if strings.TrimSpace(content) == "" {
+			log.Printf("Filtered because: no content for %s", source.Link)
+			source.Failed = web.IsTemporary(fetch_err)
+			return source, false
+		}
+		return SummarizeOrGist(source, content, openrouter_key)
+	}
+	return filter
+}
+
+// TranslateFilter fully translates a source in another language, and summarizes the translation.
+// If translating fails, the source is kept with its gist.
+func TranslateFilter(openrouter_key string) types.Filter {
+	filter := func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		if !needsTranslation(source) {
+			return source, true
+		}
+		title, err := llm.TranslateString(source.OriginalTitle, openrouter_key)
+		if err != nil {
+			log.Printf("Error translating title, keeping the gist: %v", err)
+			return source, true
+		}
+		english_text, err := llm.TranslateString(source.OriginalText, openrouter_key)
+		if err != nil {
+			log.Printf("Error translating text, keeping the gist: %v", err)
+			return source, true
+		}
+		summary, err := llm.SummarizeTranslation(english_text, openrouter_key)
+		if err != nil || summary == "" {
+			log.Printf("Error summarizing translation, keeping the gist: %v", err)
+			return source, true
+		}
+		source.Title, source.EnglishText, source.Summary = title, english_text, summary
+		log.Printf("Translated title: %s", source.Title)
+		return source, true
+	}
+	return filter
+}
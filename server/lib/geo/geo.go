@@ -0,0 +1,189 @@
+package geo
+
+import (
+	_ "embed"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// An offline gazetteer: countries plus the cities, regions and chokepoints that come up most often in the news.
+// Coordinates are rough centroids, which is enough to see where events concentrate on a map.
+
+//go:embed countries.tsv
+var countries_tsv string
+
+//go:embed places.tsv
+var places_tsv string
+
+type place struct {
+	location types.Location
+	names    []string
+}
+
+var (
+	places         []place
+	fips_to_iso    = map[string]string{}
+	iso_to_country = map[string]types.Location{}
+	// every name and alias, longest first, so that "South Sudan" wins over "Sudan"
+	patterns []pattern
+)
+
+type pattern struct {
+	name  string
+	place int
+}
+
+func parseFloat(s string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		log.Printf("Bad coordinate in gazetteer: %q", s)
+	}
+	return f
+}
+
+func splitNames(name string, aliases string) []string {
+	names := []string{name}
+	for _, alias := range strings.Split(aliases, ",") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			names = append(names, alias)
+		}
+	}
+	return names
+}
+
+func tsvRows(tsv string, n_fields int) [][]string {
+	var rows [][]string
+	for _, line := range strings.Split(tsv, "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != n_fields {
+			log.Printf("Skipping bad gazetteer line: %q", line)
+			continue
+		}
+		rows = append(rows, fields)
+	}
+	return rows
+}
+
+func init() {
+	for _, f := range tsvRows(countries_tsv, 6) {
+		location := types.Location{Name: f[0], CountryCode: f[2], Lat: parseFloat(f[4]), Lon: parseFloat(f[5])}
+		places = append(places, place{location: location, names: splitNames(f[0], f[1])})
+		fips_to_iso[f[3]] = f[2]
+		if _, ok := iso_to_country[f[2]]; !ok {
+			iso_to_country[f[2]] = location
+		}
+	}
+	for _, f := range tsvRows(places_tsv, 5) {
+		location := types.Location{Name: f[0], CountryCode: f[2], Lat: parseFloat(f[3]), Lon: parseFloat(f[4])}
+		places = append(places, place{location: location, names: splitNames(f[0], f[1])})
+	}
+	for i, p := range places {
+		for _, name := range p.names {
+			patterns = append(patterns, pattern{name: name, place: i})
+		}
+	}
+	sort.SliceStable(patterns, func(i, j int) bool {
+		return len(patterns[i].name) > len(patterns[j].name)
+	})
+}
+
+// ISOFromFIPS converts the FIPS 10-4 country codes GDELT uses into ISO 3166 ones
+func ISOFromFIPS(fips string) (string, bool) {
+	iso, ok := fips_to_iso[strings.ToUpper(fips)]
+	return iso, ok
+}
+
+// Country returns the gazetteer entry for an ISO 3166 country code
+func Country(iso string) (types.Location, bool) {
+	location, ok := iso_to_country[strings.ToUpper(iso)]
+	return location, ok
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// atWordBoundary checks that text[start:end] isn't part of a longer word, e.g. "Oman" in "Romania"
+func atWordBoundary(text string, start int, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:start])
+		if isWordRune(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolve finds the places mentioned in a text.
+// Matching is case sensitive, since place names are capitalized and this avoids most false positives ("chad", "turkey").
+func Resolve(text string) []types.Location {
+	type match struct {
+		start int
+		place int
+	}
+	taken := make([]bool, len(text))
+	found := map[int]bool{}
+	var matches []match
+
+	for _, p := range patterns {
+		offset := 0
+		for {
+			idx := strings.Index(text[offset:], p.name)
+			if idx < 0 {
+				break
+			}
+			start := offset + idx
+			end := start + len(p.name)
+			offset = end
+			if !atWordBoundary(text, start, end) || taken[start] || taken[end-1] {
+				continue
+			}
+			for i := start; i < end; i++ {
+				taken[i] = true
+			}
+			if !found[p.place] {
+				found[p.place] = true
+				matches = append(matches, match{start: start, place: p.place})
+			}
+		}
+	}
+
+	// Keep the order in which places appear in the text; the first one is usually the most relevant
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+	var locations []types.Location
+	for _, m := range matches {
+		locations = append(locations, places[m.place].location)
+	}
+	return locations
+}
+
+// CountryCodes lists the distinct countries a set of locations falls in
+func CountryCodes(locations []types.Location) []string {
+	var codes []string
+	seen := map[string]bool{}
+	for _, location := range locations {
+		if location.CountryCode == "" || seen[location.CountryCode] {
+			continue
+		}
+		seen[location.CountryCode] = true
+		codes = append(codes, location.CountryCode)
+	}
+	return codes
+}
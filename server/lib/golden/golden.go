@@ -0,0 +1,70 @@
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/* Golden files for the tests of sources, which replay recorded pages (see lib/web/replay.go)
+and compare what they parse against testdata/<name>.golden.json.
+After a parser changes on purpose, rewrite them with
+  go test . -update
+and read the diff before committing it. */
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// Check compares got, as indented json, with testdata/<name>.golden.json
+func Check(t *testing.T, name string, got any) {
+	t.Helper()
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false) // keep titles and pages readable
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(got); err != nil {
+		t.Fatalf("marshaling %s: %v", name, err)
+	}
+	data := buffer.Bytes()
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v; run go test with -update to create it", err)
+	}
+	if !bytes.Equal(data, want) {
+		line, got_line, want_line := firstDifference(string(data), string(want))
+		t.Errorf("%s differs from %s at line %d:\n got: %s\nwant: %s\nrun go test with -update if the change is intended", name, path, line, got_line, want_line)
+	}
+}
+
+func firstDifference(got string, want string) (int, string, string) {
+	got_lines := strings.Split(got, "\n")
+	want_lines := strings.Split(want, "\n")
+	for i := 0; i < max(len(got_lines), len(want_lines)); i++ {
+		var got_line, want_line string
+		if i < len(got_lines) {
+			got_line = got_lines[i]
+		}
+		if i < len(want_lines) {
+			want_line = want_lines[i]
+		}
+		if got_line != want_line {
+			return i + 1, got_line, want_line
+		}
+	}
+	return 0, "", ""
+}
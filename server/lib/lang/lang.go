@@ -0,0 +1,144 @@
+package lang
+
+import (
+	"strings"
+	"unicode"
+)
+
+/* Cheap language detection, good enough to decide whether a source needs translating.
+Most languages we care about have their own script, so we count letters by script, and only
+tell apart languages in the Latin script by their most common words. */
+
+const English = "en"
+
+var names = map[string]string{
+	"en": "English",
+	"zh": "Chinese",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"ru": "Russian",
+	"uk": "Ukrainian",
+	"ar": "Arabic",
+	"fa": "Persian",
+	"he": "Hebrew",
+	"hi": "Hindi",
+	"th": "Thai",
+	"el": "Greek",
+	"es": "Spanish",
+	"fr": "French",
+	"de": "German",
+	"pt": "Portuguese",
+	"it": "Italian",
+}
+
+// Name is the English name of a language code, e.g. "Chinese" for "zh"
+func Name(code string) string {
+	if name, ok := names[code]; ok {
+		return name
+	}
+	return code
+}
+
+// IsEnglish is also true for an unknown language, which we treat as English
+func IsEnglish(code string) bool {
+	return code == English || code == ""
+}
+
+var scripts = []struct {
+	code  string
+	table *unicode.RangeTable
+}{
+	{"zh", unicode.Han},
+	{"ja", unicode.Hiragana},
+	{"ja", unicode.Katakana},
+	{"ko", unicode.Hangul},
+	{"ru", unicode.Cyrillic},
+	{"ar", unicode.Arabic},
+	{"he", unicode.Hebrew},
+	{"hi", unicode.Devanagari},
+	{"th", unicode.Thai},
+	{"el", unicode.Greek},
+	{"en", unicode.Latin},
+}
+
+// In order of preference when tied
+var latin_languages = []string{"en", "es", "fr", "de", "pt", "it"}
+
+// The most common words of each language
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "with", "for", "was", "on", "are"},
+	"es": {"el", "los", "las", "del", "y", "que", "en", "por", "para", "una", "con", "es"},
+	"fr": {"le", "les", "des", "et", "est", "une", "dans", "pour", "du", "au", "sur", "qui"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "den", "von", "zu", "ein", "auf"},
+	"pt": {"o", "os", "as", "do", "da", "dos", "e", "que", "em", "uma", "para", "não"},
+	"it": {"il", "gli", "della", "che", "e", "di", "per", "una", "non", "sono", "nel", "con"},
+}
+
+// Detect guesses the language of a text, as an ISO 639-1 code. It returns "" if the text has no letters.
+func Detect(text string) string {
+	counts := map[string]int{}
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, script := range scripts {
+			if unicode.Is(script.table, r) {
+				counts[script.code]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+
+	// Japanese mixes kana with Han characters, so a little kana is enough
+	if counts["ja"]*10 > letters {
+		return "ja"
+	}
+	best := English
+	for _, script := range scripts {
+		if counts[script.code] > counts[best] {
+			best = script.code
+		}
+	}
+	switch best {
+	case "ru":
+		if strings.ContainsAny(text, "іїєґІЇЄҐ") {
+			return "uk"
+		}
+	case "ar":
+		if strings.ContainsAny(text, "پچژگ") {
+			return "fa"
+		}
+	case English:
+		return detectLatin(text)
+	}
+	return best
+}
+
+// detectLatin tells apart languages in the Latin script by counting their stopwords, defaulting to English
+func detectLatin(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	scores := map[string]int{}
+	for _, word := range words {
+		for code, list := range stopwords {
+			for _, stopword := range list {
+				if word == stopword {
+					scores[code]++
+				}
+			}
+		}
+	}
+	best := English
+	for _, code := range latin_languages {
+		if scores[code] > scores[best] {
+			best = code
+		}
+	}
+	return best
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"strings"
 
 	outbound "git.nunosempere.com/NunoSempere/news/lib/outbound"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
 	openai "github.com/sashabaranov/go-openai"
 	jsonschema "github.com/sashabaranov/go-openai/jsonschema"
 )
@@ -27,15 +30,27 @@ type OpenAIRequest struct {
 	prompt string
 	model  string
 	token  string
+	replay_key string // for prompts which include more than their input, like database rows; see web.WithReplayKey
 }
 
+func (req OpenAIRequest) context() context.Context {
+	if req.replay_key == "" {
+		return context.Background()
+	}
+	return web.WithReplayKey(context.Background(), req.model+"\n"+req.replay_key)
+}
+
+// LLM answers can be recorded and replayed like pages, see lib/web/replay.go
+var llm_http_client = &http.Client{Transport: web.Transport(nil)}
+
 func fetchOpenAIAnswer(req OpenAIRequest) (string, error) {
 
 	config := openai.DefaultConfig(req.token)
 	config.BaseURL = "https://openrouter.ai/api/v1"
+	config.HTTPClient = llm_http_client
 	client := openai.NewClientWithConfig(config)
 	resp, err := client.CreateChatCompletion(
-		context.Background(),
+		req.context(),
 		openai.ChatCompletionRequest{
 			Model: req.model, // openai.GPT4TurboPreview, // openai.GPT3Dot5Turbo // "gpt-3.5-turbo-0125"
 			Messages: []openai.ChatCompletionMessage{
@@ -60,9 +75,10 @@ func fetchOpenAIAnswerJSON(req OpenAIRequest, schema openai.ChatCompletionRespon
 
 	config := openai.DefaultConfig(req.token)
 	config.BaseURL = "https://openrouter.ai/api/v1"
+	config.HTTPClient = llm_http_client
 	client := openai.NewClientWithConfig(config)
 	resp, err := client.CreateChatCompletion(
-		context.Background(),
+		req.context(),
 		openai.ChatCompletionRequest{
 			Model: req.model, // openai.GPT4TurboPreview, // openai.GPT3Dot5Turbo // "gpt-3.5-turbo-0125"
 			Messages: []openai.ChatCompletionMessage{
@@ -114,7 +130,17 @@ type SummaryBox struct {
 }
 
 func Summarize(text string, token string) (string, error) {
-	prompt := "The json API endpoint returns a {summary, error} object, like {summary: \"The article is about xyz\", error: null}. The summary contains, as a string, first a general summary of the contents of the article article in two paragraphs or less, and then an outline with the most salient, new and informative facts in an additional paragraph. The summary just states the contents of the article, and doesn't say \"The article says\" or similar introductions. For example, given the following article\n\n<INPUT>"
+	return summarize(text, "", token)
+}
+
+// SummarizeTranslation summarizes an article machine-translated from another language, see lib/filters/translate.go
+func SummarizeTranslation(text string, token string) (string, error) {
+	return summarize(text, " The article was translated from another language, so the summary gives its gist in idiomatic English, rather than keeping the phrasing of the original.", token)
+}
+
+// summarize adds instructions to the prompt, outside of the article, so that they can't be mistaken for part of it
+func summarize(text string, instructions string, token string) (string, error) {
+	prompt := "The json API endpoint returns a {summary, error} object, like {summary: \"The article is about xyz\", error: null}. The summary contains, as a string, first a general summary of the contents of the article article in two paragraphs or less, and then an outline with the most salient, new and informative facts in an additional paragraph. The summary just states the contents of the article, and doesn't say \"The article says\" or similar introductions." + instructions + " For example, given the following article\n\n<INPUT>"
 	prompt += text + "\n\n</INPUT>\n\nThe output is as follows (as a reminder, the json API endpoint returns a {summary, error} object, like {summary: \"The article is about xyz\", error: null}. The summary contains, as a string, first a general summary of the article in two paragraphs or less, and then an outline outlines the most salient, new and informative facts in an additional paragraph):"
 	prompt += "<INPUT>" + text + "</INPUT>"
 
@@ -154,114 +180,196 @@ type ExistentialImportanceBox struct {
 	Error                          *string `json:"error"`
 }
 
+// CheckExistentialImportance checks importance with the default profile, see profiles.go
 func CheckExistentialImportance(text string, token string) (*ExistentialImportanceBox, error) {
-	prompt := `The existential importance json API endpoint returns a {existential_importance_reasoning, existential_importance_bool, high_importance_bool, error} object.
-
-The existential_importance_reasoning field contains, as a string, a determination of whether the input describes an event of global importance. existential_importance_bool contains the result of that determination as a true/false boolean. high_importance_bool contains, as a true/false boolean, whether the event is highly important, even if it is not of "existential" importance.
-
-Items are of "existential importance" if:
-
-- They involve more than a hundred deaths.
-- They involve many cases of a sickness that might spread, or a new pathogen
-- They involve conflict between nuclear powers
-- They involve conflict that could escalate into global conflict, even if it hasn't already
-- They involve terrorist groups displaying new capabilities
-- They involve new AI advancements or shifts in the AI industry in particular.
-- ... and in general, if they involve events that could threaten humanity as a whole
-
-For example:
-
-- Houthis cut undersea internet cables: Meets existential importance threshold, because it is a terrorist group displaying new capabilities.
-- Macron suggests sending NATO troops to Ukraine: is of existential importance, as a NATO v. Russia conflict could spiral into a global war.
-- New, more deadly and infectious strain of covid detected in Lausanne: is of existential importance, as the a deadly pandemic is one of the ways a large swathe of humanity could die at once.
-- OpenAI releases new capable model: is of existential importance, as that model could be used by bad actors to cause mayhem, or it itself could (conceivably) threaten humanity in a Terminator-like scenario.
-- US company lands probe in the Moon: is of high importance but it is not of existential importance, as it doesn't threaten humanity. 
-- Start of a war (e.g., the start of the war in Ukraine): Almost always of existential importance, as rocking the international status quo could spiral out. 
-- Later developments of a war (e.g,. current war in Gaza, or current war in Ukraine): probably not of existential importance, as the likelihood of spiraling out declines as the rules of engagement become clearer. Probably still of high importance (just not existentially so).
-- For the purposes of this API, opinion and discussion pieces are not categorized as existentially important. A sign something is an opinion piece—as opposed to considering new events—is a somewhat generic title, like "Why Nuclear Risks Have Not Gone Away", or "At the Brink: Confronting the Risk of Nuclear War". Review articles and lists of events are likewise not existentially important unless they bring up novel events.
-- In a broader conflict, small-fry developments are not existentially important. For example, small developments in the Ukraine or Gaza wars are not existentially important unless the new events themselves involve more than 1k deaths, even if the conflict as a whole involves more than that number of deaths. On the other hand, developments involving escalations or nuclear weapons are not "small fry"
-- Car crashes or accidents like floods are not existentially important. However an AMOC reversal would be.
-- We are in 2026. Reviews of past conflicts, like 9/11, no longer count as existentially important, even if they were so at the time.
-
-For a longer example, given the following item\n\n<INPUT>`
-	prompt += text + "\n\n</INPUT>\n\nThe output is as follows: (As a reminder, the existential importance json API endpoint returns a {existential_importance_reasoning, existential_importance_bool, high_importance_bool, error} object, opinion pieces, or editorials are not categorizes as existentially important.)\n"
-
-	var existential_importance_box ExistentialImportanceBox
-	schema, err := jsonschema.GenerateSchemaForType(existential_importance_box)
+	return CheckImportanceWithProfile(text, DefaultProfile, token)
+}
+
+type StoryDeltaBox struct {
+	StoryIndex     int     `json:"story_index"`
+	NewStoryName   string  `json:"new_story_name"`
+	Delta          string  `json:"delta"`
+	DeltaReasoning string  `json:"delta_reasoning"`
+	UpdatedState   string  `json:"updated_state"`
+	Error          *string `json:"error"`
+}
+
+// ClassifyStoryDelta links an article to one of the given ongoing stories (or proposes a new one),
+// and classifies it as an escalation, de-escalation or no change relative to the story's latest state
+func ClassifyStoryDelta(text string, stories []string, token string) (*StoryDeltaBox, error) {
+	prompt := `The story tracking json API endpoint returns a {story_index, new_story_name, delta, delta_reasoning, updated_state, error} object.
+
+Stories are long-lived developments, like "Red Sea shipping attacks", "Russian invasion of Ukraine" or "H5N1 outbreak in US dairy cattle", which generate new articles every day. The endpoint receives a numbered list of ongoing stories, each with a description of its latest state, and a new article.
+
+- story_index contains the number of the story the article belongs to, or -1 if it doesn't belong to any of them.
+- new_story_name contains, if story_index is -1, a short and general name for a new story the article would start, like "Red Sea shipping attacks". Otherwise it is an empty string.
+- delta contains exactly one of "escalation", "de-escalation" or "no-change", describing what the article means relative to the latest state of the story. For a new story, the delta is "escalation" if the article describes a new threat, and "no-change" otherwise.
+- delta_reasoning contains a sentence explaining the delta.
+- updated_state contains, in two sentences or less, the latest state of the story once the article is taken into account.
+
+Escalations are, for example, new attacks, more deaths, new actors joining a conflict, new weapons being used, a pathogen spreading to new places or hosts. De-escalations are ceasefires, negotiations, withdrawals, falling case counts. Articles that repeat what is already known, or which discuss the story without new events, are "no-change".
+
+The ongoing stories are:
+
+<STORIES>
+`
+	if len(stories) == 0 {
+		prompt += "(none yet)\n"
+	}
+	for i, story := range stories {
+		prompt += fmt.Sprintf("%d. %s\n", i, story)
+	}
+	prompt += "</STORIES>\n\nThe new article is:\n\n<INPUT>" + text + "\n\n</INPUT>\n\nThe output is as follows: (As a reminder, the story tracking json API endpoint returns a {story_index, new_story_name, delta, delta_reasoning, updated_state, error} object)\n"
+
+	var story_delta_box StoryDeltaBox
+	schema, err := jsonschema.GenerateSchemaForType(story_delta_box)
 	if err != nil {
 		log.Fatalf("GenerateSchemaForType error: %v", err)
 	}
 	openai_schema := openai.ChatCompletionResponseFormatJSONSchema{
-		Name:   "ExistentialImportanceBox",
+		Name:   "StoryDeltaBox",
 		Schema: schema,
 		Strict: true,
 	}
-	answer_json, err := fetchOpenAIAnswerJSON(OpenAIRequest{prompt: prompt, model: DEFAULT_MODEL, token: token}, openai_schema)
+	// The open stories come from the database, so recordings are keyed on the article alone, see web.WithReplayKey
+	answer_json, err := fetchOpenAIAnswerJSON(OpenAIRequest{prompt: prompt, model: DEFAULT_MODEL, token: token, replay_key: "story delta\n" + text}, openai_schema)
+	if err != nil {
+		return nil, err
+	}
 
-	err = json.Unmarshal([]byte(answer_json), &existential_importance_box)
+	err = json.Unmarshal([]byte(answer_json), &story_delta_box)
 	if err != nil {
 		log.Printf("Error unmarshalling json: %v", err)
 		return nil, err
 	}
-	if existential_importance_box.Error != nil && *existential_importance_box.Error != "" && *existential_importance_box.Error != "null" {
-		log.Printf("OpenAI json error field is not empty: %v", err)
+	if story_delta_box.Error != nil && *story_delta_box.Error != "" && *story_delta_box.Error != "null" {
+		log.Printf("OpenAI json error field is not empty: %v", *story_delta_box.Error)
 		log.Printf("OpenAI answer: %v", answer_json)
-		return nil, err
+		return nil, errors.New(*story_delta_box.Error)
+	}
+	if story_delta_box.StoryIndex >= len(stories) || story_delta_box.StoryIndex < -1 {
+		log.Printf("Story index out of range: %d", story_delta_box.StoryIndex)
+		return nil, fmt.Errorf("story index out of range: %d", story_delta_box.StoryIndex)
 	}
-	return &existential_importance_box, nil
+	return &story_delta_box, nil
 }
 
-func CheckExistentialImportanceChina(text string, token string) (*ExistentialImportanceBox, error) {
-	prompt := `The existential importance json API endpoint returns a {existential_importance_reasoning, existential_importance_bool, high_importance_bool, error} object.
+type EventBox struct {
+	Countries    []string `json:"countries"`
+	Actors       []string `json:"actors"`
+	EventType    string   `json:"event_type"`
+	Killed       int      `json:"killed"`
+	Wounded      int      `json:"wounded"`
+	Affected     int      `json:"affected"`
+	Pathogen     string   `json:"pathogen"`
+	WeaponSystem string   `json:"weapon_system"`
+	Error        *string  `json:"error"`
+}
 
-The existential_importance_reasoning field contains, as a string, a determination of whether the input describes an event of global importance. existential_importance_bool contains the result of that determination as a true/false boolean. high_importance_bool contains, as a true/false boolean, whether the event is highly important, even if it is not of "existential" importance.
+var EventTypes = []string{"armed conflict", "terrorism", "military buildup", "nuclear", "disease outbreak", "natural disaster", "industrial accident", "cyberattack", "political crisis", "economic crisis", "ai development", "other"}
 
-Items are of existential importance if:
+// ExtractEvent pulls structured fields out of an article, so that events can be queried and fed to quantitative forecasts
+func ExtractEvent(text string, token string) (*EventBox, error) {
+	prompt := `The event extraction json API endpoint returns a {countries, actors, event_type, killed, wounded, affected, pathogen, weapon_system, error} object describing the main event in the input.
 
-- They involve conflict between China and other world powers, like the US
-- They involve a potential Chinese invasion of Taiwan
-- They involve displays of new technologies with offensive capabilities, like drones, amphibious vehicles, etc.
-- They involve more than a hundred deaths.
-- They involve many cases of a sickness that might spread, or a new pathogen
-- They involve conflict that could escalate into global conflict, even if it hasn't already
-- They involve an attempt at consensus building within a population for an important conflict
+- countries contains the ISO 3166-1 alpha-2 codes of the countries where the event takes place, or which are directly involved, like ["UA", "RU"].
+- actors contains the names of the main governments, armed groups, organizations or people involved, like ["Houthis", "US Navy"].
+- event_type contains exactly one of: ` + strings.Join(EventTypes, ", ") + `.
+- killed, wounded and affected contain the number of people killed, wounded, and otherwise affected (infected, displaced, evacuated, etc.) in the event described by the input, as integers. If the input doesn't give a number, the field is -1. If it gives a range, the field is the lower end of the range. Totals for a whole war or epidemic only count if the input is about that total.
+- pathogen contains the name of the pathogen involved, like "H5N1", or an empty string.
+- weapon_system contains the name of the main weapon system involved, like "Shahed-136 drone" or "ICBM", or an empty string.
 
-Keeping to China-related examples, the following would be existentially important
+Given the following input
 
-- China prepares for an invasion of Taiwan 
-- China demonstrates new drone or amphibious capabilities
-- China carries out military exercises in the Taiwan strait
-- An article in a Chinese newspaper builds consensus around needing to use force to keep Taiwan from declaring independence
-- etc.
+<INPUT>`
+	prompt += text + "\n\n</INPUT>\n\nThe output is as follows: (As a reminder, the event extraction json API endpoint returns a {countries, actors, event_type, killed, wounded, affected, pathogen, weapon_system, error} object, and unknown counts are -1)\n"
 
-For now, the API leans towards having a light trigger, because false positives are less costly than false negatives.
+	var event_box EventBox
+	schema, err := jsonschema.GenerateSchemaForType(event_box)
+	if err != nil {
+		log.Fatalf("GenerateSchemaForType error: %v", err)
+	}
+	openai_schema := openai.ChatCompletionResponseFormatJSONSchema{
+		Name:   "EventBox",
+		Schema: schema,
+		Strict: true,
+	}
+	answer_json, err := fetchOpenAIAnswerJSON(OpenAIRequest{prompt: prompt, model: DEFAULT_MODEL, token: token}, openai_schema)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(answer_json), &event_box)
+	if err != nil {
+		log.Printf("Error unmarshalling json: %v", err)
+		return nil, err
+	}
+	if event_box.Error != nil && *event_box.Error != "" && *event_box.Error != "null" {
+		log.Printf("OpenAI json error field is not empty: %v", *event_box.Error)
+		log.Printf("OpenAI answer: %v", answer_json)
+		return nil, errors.New(*event_box.Error)
+	}
+	return &event_box, nil
+}
+
+type OutbreakBox struct {
+	Pathogen     string   `json:"pathogen"`
+	Disease      string   `json:"disease"`
+	Countries    []string `json:"countries"`
+	Locations    []string `json:"locations"`
+	Cases        int      `json:"cases"`
+	Deaths       int      `json:"deaths"`
+	HumanToHuman string   `json:"human_to_human"`
+	Novel        bool     `json:"novel"`
+	Error        *string  `json:"error"`
+}
+
+var HumanToHumanSignals = []string{"none", "suspected", "confirmed", "not mentioned"}
 
-For a longer example, given the following article\n\n<INPUT>`
-	prompt += text + "\n\n</INPUT>\n\nThe output is as follows: (As a reminder, the existential importance json API endpoint returns a {existential_importance_reasoning, existential_importance_bool, high_importance_bool, error} object, opinion pieces, or editorials are not categorizes as existentially important.)\n"
+// ExtractOutbreak pulls the pathogen, places and case counts out of an outbreak report, like a WHO Disease Outbreak News item,
+// along with whether it reports human-to-human transmission of a pathogen which usually spreads otherwise
+func ExtractOutbreak(text string, token string) (*OutbreakBox, error) {
+	prompt := `The outbreak extraction json API endpoint returns a {pathogen, disease, countries, locations, cases, deaths, human_to_human, novel, error} object describing the main outbreak in the input.
 
-	var existential_importance_box ExistentialImportanceBox
-	schema, err := jsonschema.GenerateSchemaForType(existential_importance_box)
+- pathogen contains the name of the pathogen, as specific as the input allows, like "Influenza A(H5N1), clade 2.3.4.4b" or "Marburg virus", or an empty string if it isn't known yet.
+- disease contains the name of the disease, like "avian influenza" or "Marburg virus disease", or "unexplained illness" for clusters without a known cause.
+- countries contains the ISO 3166-1 alpha-2 codes of the countries with cases, like ["KH"].
+- locations contains the names of the provinces, districts or cities with cases, like ["Takeo Province"].
+- cases and deaths contain the total numbers of human cases (confirmed, probable and suspected) and deaths in the outbreak reported by the input, as integers. If the input doesn't give a number, the field is -1.
+- human_to_human contains exactly one of: ` + strings.Join(HumanToHumanSignals, ", ") + `. It is "suspected" for signs like clusters of cases without exposure to animals, infected healthcare workers or secondary cases among contacts, and "none" if the input says there is no evidence of it. For pathogens which usually spread between people, like measles or cholera, it is "not mentioned" unless the input discusses it.
+- novel contains, as a true/false boolean, whether the pathogen is new, new in humans, like a new influenza subtype, or shows new properties, like resistance to treatments or vaccines.
+
+Given the following input
+
+<INPUT>`
+	prompt += text + "\n\n</INPUT>\n\nThe output is as follows: (As a reminder, the outbreak extraction json API endpoint returns a {pathogen, disease, countries, locations, cases, deaths, human_to_human, novel, error} object, and unknown counts are -1)\n"
+
+	var outbreak_box OutbreakBox
+	schema, err := jsonschema.GenerateSchemaForType(outbreak_box)
 	if err != nil {
 		log.Fatalf("GenerateSchemaForType error: %v", err)
 	}
 	openai_schema := openai.ChatCompletionResponseFormatJSONSchema{
-		Name:   "ExistentialImportanceBox",
+		Name:   "OutbreakBox",
 		Schema: schema,
 		Strict: true,
 	}
 	answer_json, err := fetchOpenAIAnswerJSON(OpenAIRequest{prompt: prompt, model: DEFAULT_MODEL, token: token}, openai_schema)
+	if err != nil {
+		return nil, err
+	}
 
-	err = json.Unmarshal([]byte(answer_json), &existential_importance_box)
+	err = json.Unmarshal([]byte(answer_json), &outbreak_box)
 	if err != nil {
 		log.Printf("Error unmarshalling json: %v", err)
 		return nil, err
 	}
-	if existential_importance_box.Error != nil && *existential_importance_box.Error != "" && *existential_importance_box.Error != "null" {
-		log.Printf("OpenAI json error field is not empty: %v", err)
+	if outbreak_box.Error != nil && *outbreak_box.Error != "" && *outbreak_box.Error != "null" {
+		log.Printf("OpenAI json error field is not empty: %v", *outbreak_box.Error)
 		log.Printf("OpenAI answer: %v", answer_json)
-		return nil, err
+		return nil, errors.New(*outbreak_box.Error)
 	}
-	return &existential_importance_box, nil
+	return &outbreak_box, nil
 }
 
 func TranslateString(text string, token string) (string, error) {
@@ -275,6 +383,162 @@ func TranslateString(text string, token string) (string, error) {
 
 }
 
+type GistBox struct {
+	EnglishTitle string  `json:"english_title"`
+	Gist         string  `json:"gist"`
+	Error        *string `json:"error"`
+}
+
+// Gist gives the English title and gist of an article in another language, with the cheap model,
+// so that we can check its importance before paying for a full translation
+func Gist(title string, text string, language string, token string) (*GistBox, error) {
+	prompt := `The gist json API endpoint returns a {english_title, gist, error} object for an article written in ` + language + `.
+
+- english_title contains the title of the article, translated into idiomatic English.
+- gist contains, in English, one paragraph with what the article reports: who did what, where and when, and any numbers it gives. It doesn't say "The article says" or similar introductions.
+
+Given the following article, which may be cut short
+
+<INPUT>
+# ` + title + "\n\n" + text + "\n\n</INPUT>\n\nThe output is as follows: (As a reminder, the gist json API endpoint returns a {english_title, gist, error} object, in English)\n"
+
+	var gist_box GistBox
+	schema, err := jsonschema.GenerateSchemaForType(gist_box)
+	if err != nil {
+		log.Fatalf("GenerateSchemaForType error: %v", err)
+	}
+	openai_schema := openai.ChatCompletionResponseFormatJSONSchema{
+		Name:   "GistBox",
+		Schema: schema,
+		Strict: true,
+	}
+	answer_json, err := fetchOpenAIAnswerJSON(OpenAIRequest{prompt: prompt, model: DEFAULT_MODEL, token: token}, openai_schema)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(answer_json), &gist_box)
+	if err != nil {
+		log.Printf("Error unmarshalling json: %v", err)
+		return nil, err
+	}
+	if gist_box.Error != nil && *gist_box.Error != "" && *gist_box.Error != "null" {
+		log.Printf("OpenAI json error field is not empty: %v", *gist_box.Error)
+		log.Printf("OpenAI answer: %v", answer_json)
+		return nil, errors.New(*gist_box.Error)
+	}
+	return &gist_box, nil
+}
+
+type PageChangeBox struct {
+	Title    string  `json:"title"`
+	Summary  string  `json:"summary"`
+	Category string  `json:"category"`
+	Error    *string `json:"error"`
+}
+
+var PageChangeCategories = []string{"new frontier model", "model update", "safety-policy change", "usage-policy change", "eval result", "minor edit"}
+
+// DescribePageChange says what changed on a page an AI lab maintains, like a model card or a safety policy, given a diff of its paragraphs
+func DescribePageChange(lab string, page string, diff string, token string) (*PageChangeBox, error) {
+	prompt := `The page change json API endpoint returns a {title, summary, category, error} object describing a change to a page maintained by an AI lab.
+
+- title contains a news headline for the change, like "Anthropic adds new capability thresholds to its Responsible Scaling Policy".
+- summary contains one or two paragraphs saying what was added, removed or changed, and why it might matter, quoting the changed text where useful. It doesn't say "The diff shows" or similar introductions.
+- category contains exactly one of: ` + strings.Join(PageChangeCategories, ", ") + `. Changes to wording, formatting, dates, navigation or prices without other consequences are a "minor edit".
+
+The page is "` + page + `" by ` + lab + `. Removed paragraphs start with "- ", and added ones with "+ ". Given the following diff
+
+<INPUT>
+` + diff + "\n\n</INPUT>\n\nThe output is as follows: (As a reminder, the page change json API endpoint returns a {title, summary, category, error} object)\n"
+
+	var page_change_box PageChangeBox
+	schema, err := jsonschema.GenerateSchemaForType(page_change_box)
+	if err != nil {
+		log.Fatalf("GenerateSchemaForType error: %v", err)
+	}
+	openai_schema := openai.ChatCompletionResponseFormatJSONSchema{
+		Name:   "PageChangeBox",
+		Schema: schema,
+		Strict: true,
+	}
+	answer_json, err := fetchOpenAIAnswerJSON(OpenAIRequest{prompt: prompt, model: DEFAULT_MODEL, token: token}, openai_schema)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(answer_json), &page_change_box)
+	if err != nil {
+		log.Printf("Error unmarshalling json: %v", err)
+		return nil, err
+	}
+	if page_change_box.Error != nil && *page_change_box.Error != "" && *page_change_box.Error != "null" {
+		log.Printf("OpenAI json error field is not empty: %v", *page_change_box.Error)
+		log.Printf("OpenAI answer: %v", answer_json)
+		return nil, errors.New(*page_change_box.Error)
+	}
+	return &page_change_box, nil
+}
+
+type TitleTriage struct {
+	Index    int    `json:"index"`
+	Alarm    int    `json:"alarm"`
+	Category string `json:"category"`
+}
+
+type TitleTriageBox struct {
+	Items []TitleTriage `json:"items"`
+	Error *string       `json:"error"`
+}
+
+var AlarmCategories = []string{"none", "illness or outbreak", "financial panic", "AI psychosis or parasitism", "AI incident", "war or military", "disaster", "unrest", "infrastructure failure", "other"}
+
+// TriageTitles scores how alarming many post titles are in one go, with the cheap model, so that
+// we can watch many forums without an LLM call per post. titles are numbered from 1 in the prompt.
+func TriageTitles(titles []string, token string) (*TitleTriageBox, error) {
+	var input strings.Builder
+	for i, title := range titles {
+		fmt.Fprintf(&input, "%d. %s\n", i+1, title)
+	}
+	prompt := `The title triage json API endpoint returns a {items, error} object, where items has one {index, alarm, category} object for each numbered title in the input.
+
+- index contains the number of the title.
+- alarm contains, as an integer from 0 to 10, how alarming the title is as a sign of something which could harm many people: 0 for ordinary posts, 5 for a worrying local report, like several people in one town falling ill with the same unexplained symptoms, and 10 for a clear report of a catastrophe. Jokes, memes, questions about personal matters and news everyone already knows about score low.
+- category contains exactly one of: ` + strings.Join(AlarmCategories, ", ") + `. Titles with an alarm below 3 are "none".
+
+The titles are posts on online forums, prefixed with their forum. Given the following titles
+
+<INPUT>
+` + input.String() + "\n</INPUT>\n\nThe output is as follows: (As a reminder, the title triage json API endpoint returns a {items, error} object, with one item per title)\n"
+
+	var title_triage_box TitleTriageBox
+	schema, err := jsonschema.GenerateSchemaForType(title_triage_box)
+	if err != nil {
+		log.Fatalf("GenerateSchemaForType error: %v", err)
+	}
+	openai_schema := openai.ChatCompletionResponseFormatJSONSchema{
+		Name:   "TitleTriageBox",
+		Schema: schema,
+		Strict: true,
+	}
+	answer_json, err := fetchOpenAIAnswerJSON(OpenAIRequest{prompt: prompt, model: DEFAULT_MODEL, token: token}, openai_schema)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(answer_json), &title_triage_box)
+	if err != nil {
+		log.Printf("Error unmarshalling json: %v", err)
+		return nil, err
+	}
+	if title_triage_box.Error != nil && *title_triage_box.Error != "" && *title_triage_box.Error != "null" {
+		log.Printf("OpenAI json error field is not empty: %v", *title_triage_box.Error)
+		log.Printf("OpenAI answer: %v", answer_json)
+		return nil, errors.New(*title_triage_box.Error)
+	}
+	return &title_triage_box, nil
+}
+
 func MergeArticles(text string, token string) (string, error) {
 	prompt := "Consider the following list of articles and their summaries. Your task is to clean it up.\n\n" +
 		"1. If there are many articles, add a tl;dr at the top with the events which would most likely end up with > 1M deaths. Make this a paragraph starting with <p><b>tl;dr:</b>..., not an h1 element\n" +
@@ -0,0 +1,226 @@
+package llm
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	openai "github.com/sashabaranov/go-openai"
+	jsonschema "github.com/sashabaranov/go-openai/jsonschema"
+)
+
+/* Importance profiles are the criteria and examples the importance check gives the LLM, for one focus:
+a region, like China and Taiwan, or a topic, like biosecurity. Sources can pick a profile, or let
+DetectProfile pick one from what an article mentions. The profiles are in profiles.json, and can be
+replaced without recompiling by pointing IMPORTANCE_PROFILES_FILE to a file in the same format. */
+
+const DefaultProfile = "default"
+
+// A region or topic needs this many mentions (keywords or countries) before its profile is picked
+const minProfileScore = 2
+
+type Profile struct {
+	Name            string   `json:"name"`
+	Description     string   `json:"description"`
+	Base            string   `json:"base,omitempty"`           // profile whose criteria and examples follow this one's
+	Criteria        []string `json:"criteria"`                 // what makes an item existentially important
+	ExamplesIntro   string   `json:"examples_intro,omitempty"` // defaults to "For example:"
+	Examples        []string `json:"examples,omitempty"`
+	Threshold       string   `json:"threshold,omitempty"` // "light", "strict", or empty for neither
+	Keywords        []string `json:"keywords,omitempty"`  // for DetectProfile; case-insensitive whole words
+	Countries       []string `json:"countries,omitempty"` // for DetectProfile; ISO 3166-1 alpha-2 codes
+	keyword_regexes []*regexp.Regexp
+}
+
+var threshold_notes = map[string]string{
+	"":       "",
+	"light":  "For now, the API leans towards having a light trigger, because false positives are less costly than false negatives.",
+	"strict": "The API has a high bar: when in doubt, items are not of existential importance.",
+}
+
+//go:embed profiles.json
+var default_profiles []byte
+
+var (
+	profiles      []Profile
+	profiles_once sync.Once
+)
+
+func parseProfiles(data []byte) ([]Profile, error) {
+	var parsed []Profile
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, profile := range parsed {
+		names[profile.Name] = true
+	}
+	if !names[DefaultProfile] {
+		return nil, fmt.Errorf("there is no %q profile", DefaultProfile)
+	}
+	for i := range parsed {
+		profile := &parsed[i]
+		if profile.Name == "" || len(profile.Criteria) == 0 {
+			return nil, fmt.Errorf("profile #%d (%q) needs a name and criteria", i+1, profile.Name)
+		}
+		if profile.Base != "" && (!names[profile.Base] || profile.Base == profile.Name) {
+			return nil, fmt.Errorf("profile %s has an unknown base %q", profile.Name, profile.Base)
+		}
+		if _, ok := threshold_notes[profile.Threshold]; !ok {
+			return nil, fmt.Errorf("profile %s has an unknown threshold %q", profile.Name, profile.Threshold)
+		}
+		for _, keyword := range profile.Keywords {
+			// \b only knows about ascii, so e.g. Chinese keywords are matched anywhere
+			pattern := regexp.QuoteMeta(keyword)
+			if strings.IndexFunc(keyword, func(r rune) bool { return r > 127 }) < 0 {
+				pattern = `\b` + pattern + `\b`
+			}
+			profile.keyword_regexes = append(profile.keyword_regexes, regexp.MustCompile("(?i)"+pattern))
+		}
+	}
+	return parsed, nil
+}
+
+// loadProfiles reads IMPORTANCE_PROFILES_FILE, if set, the first time profiles are needed,
+// so that it can come from a .env file loaded in main
+func loadProfiles() []Profile {
+	profiles_once.Do(func() {
+		parsed, err := parseProfiles(default_profiles)
+		if err != nil {
+			log.Fatalf("Error parsing embedded importance profiles: %v", err)
+		}
+		profiles = parsed
+		path := os.Getenv("IMPORTANCE_PROFILES_FILE")
+		if path == "" {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			parsed, err = parseProfiles(data)
+		}
+		if err != nil {
+			log.Printf("Error loading importance profiles from %s, using the default ones: %v", path, err)
+			return
+		}
+		profiles = parsed
+		log.Printf("Loaded %d importance profiles from %s", len(profiles), path)
+	})
+	return profiles
+}
+
+// GetProfile finds a profile by name
+func GetProfile(name string) (Profile, bool) {
+	for _, profile := range loadProfiles() {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return Profile{}, false
+}
+
+// DetectProfile picks the profile whose keywords and countries an article mentions most,
+// or the default profile if none is mentioned enough
+func DetectProfile(text string, country_codes []string) string {
+	best, best_score := DefaultProfile, minProfileScore-1
+	for _, profile := range loadProfiles() {
+		score := 0
+		for _, regex := range profile.keyword_regexes {
+			score += len(regex.FindAllStringIndex(text, -1))
+		}
+		for _, code := range country_codes {
+			if slices.Contains(profile.Countries, code) {
+				score++
+			}
+		}
+		if score > best_score {
+			best, best_score = profile.Name, score
+		}
+	}
+	return best
+}
+
+// criteriaAndExamples follows a profile's chain of bases
+func criteriaAndExamples(profile Profile) ([]string, []string) {
+	criteria, examples := slices.Clone(profile.Criteria), []string{}
+	if len(profile.Examples) > 0 {
+		intro := profile.ExamplesIntro
+		if intro == "" {
+			intro = "For example:"
+		}
+		examples = append(examples, intro+"\n\n- "+strings.Join(profile.Examples, "\n- "))
+	}
+	seen := map[string]bool{profile.Name: true}
+	for base, ok := GetProfile(profile.Base); ok && !seen[base.Name]; base, ok = GetProfile(base.Base) {
+		seen[base.Name] = true
+		for _, criterion := range base.Criteria {
+			if !slices.Contains(criteria, criterion) {
+				criteria = append(criteria, criterion)
+			}
+		}
+		if len(base.Examples) > 0 {
+			examples = append(examples, "More generally:\n\n- "+strings.Join(base.Examples, "\n- "))
+		}
+	}
+	return criteria, examples
+}
+
+func importancePrompt(profile Profile, text string) string {
+	criteria, examples := criteriaAndExamples(profile)
+	prompt := `The existential importance json API endpoint returns a {existential_importance_reasoning, existential_importance_bool, high_importance_bool, error} object.
+
+The existential_importance_reasoning field contains, as a string, a determination of whether the input describes an event of global importance. existential_importance_bool contains the result of that determination as a true/false boolean. high_importance_bool contains, as a true/false boolean, whether the event is highly important, even if it is not of "existential" importance.
+
+Items are of "existential importance" if:
+
+- ` + strings.Join(criteria, "\n- ") + "\n\n" + strings.Join(examples, "\n\n")
+	if note := threshold_notes[profile.Threshold]; note != "" {
+		prompt += "\n\n" + note
+	}
+	prompt += "\n\nFor a longer example, given the following item\n\n<INPUT>"
+	prompt += text + "\n\n</INPUT>\n\nThe output is as follows: (As a reminder, the existential importance json API endpoint returns a {existential_importance_reasoning, existential_importance_bool, high_importance_bool, error} object, opinion pieces, or editorials are not categorizes as existentially important.)\n"
+	return prompt
+}
+
+// CheckImportanceWithProfile checks whether an item is existentially important, by the criteria of a profile
+func CheckImportanceWithProfile(text string, profile_name string, token string) (*ExistentialImportanceBox, error) {
+	profile, ok := GetProfile(profile_name)
+	if !ok {
+		log.Printf("Unknown importance profile %q, using the default one", profile_name)
+		profile, _ = GetProfile(DefaultProfile)
+	}
+	prompt := importancePrompt(profile, text)
+
+	var existential_importance_box ExistentialImportanceBox
+	schema, err := jsonschema.GenerateSchemaForType(existential_importance_box)
+	if err != nil {
+		log.Fatalf("GenerateSchemaForType error: %v", err)
+	}
+	openai_schema := openai.ChatCompletionResponseFormatJSONSchema{
+		Name:   "ExistentialImportanceBox",
+		Schema: schema,
+		Strict: true,
+	}
+	answer_json, err := fetchOpenAIAnswerJSON(OpenAIRequest{prompt: prompt, model: DEFAULT_MODEL, token: token}, openai_schema)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(answer_json), &existential_importance_box)
+	if err != nil {
+		log.Printf("Error unmarshalling json: %v", err)
+		return nil, err
+	}
+	if existential_importance_box.Error != nil && *existential_importance_box.Error != "" && *existential_importance_box.Error != "null" {
+		log.Printf("OpenAI json error field is not empty: %v", *existential_importance_box.Error)
+		log.Printf("OpenAI answer: %v", answer_json)
+		return nil, errors.New(*existential_importance_box.Error)
+	}
+	return &existential_importance_box, nil
+}
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS stories (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    latest_state TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS story_events (
+    id SERIAL PRIMARY KEY,
+    story_id INTEGER NOT NULL REFERENCES stories(id) ON DELETE CASCADE,
+    link TEXT NOT NULL,
+    title TEXT NOT NULL,
+    date TIMESTAMP NOT NULL,
+    delta TEXT NOT NULL, -- 'escalation', 'de-escalation' or 'no-change'
+    delta_reasoning TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (story_id, link)
+);
+
+CREATE INDEX IF NOT EXISTS story_events_link_idx ON story_events (link);
@@ -0,0 +1,11 @@
+ALTER TABLE sources
+    ADD COLUMN IF NOT EXISTS event_type TEXT,
+    ADD COLUMN IF NOT EXISTS countries TEXT[],
+    ADD COLUMN IF NOT EXISTS actors TEXT[],
+    ADD COLUMN IF NOT EXISTS killed INTEGER,
+    ADD COLUMN IF NOT EXISTS wounded INTEGER,
+    ADD COLUMN IF NOT EXISTS affected INTEGER,
+    ADD COLUMN IF NOT EXISTS pathogen TEXT,
+    ADD COLUMN IF NOT EXISTS weapon_system TEXT;
+
+CREATE INDEX IF NOT EXISTS sources_killed_idx ON sources (killed) WHERE killed IS NOT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE sources
+    ADD COLUMN IF NOT EXISTS locations JSONB,
+    ADD COLUMN IF NOT EXISTS country_codes TEXT[];
+
+CREATE INDEX IF NOT EXISTS sources_country_codes_idx ON sources USING GIN (country_codes);
@@ -0,0 +1,7 @@
+-- The last 15 minute window the gdelt source processed, per kind of file ("gkg", "events"),
+-- so that it can catch up on missed windows after being down
+CREATE TABLE IF NOT EXISTS gdelt_progress (
+    kind TEXT PRIMARY KEY,
+    last_processed TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,12 @@
+-- Running totals per Google Alerts keyword, to find alerts which never produce anything
+CREATE TABLE IF NOT EXISTS galerts_stats (
+    keyword TEXT PRIMARY KEY,
+    feed_url TEXT NOT NULL,
+    fetched BIGINT NOT NULL DEFAULT 0,
+    passed BIGINT NOT NULL DEFAULT 0,
+    kept BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    last_fetch TIMESTAMP,
+    last_success TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
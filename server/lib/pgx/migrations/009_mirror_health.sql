@@ -0,0 +1,11 @@
+-- How the mirrors in lib/resolve have been doing, so that dead ones are skipped
+CREATE TABLE IF NOT EXISTS mirror_health (
+    mirror TEXT PRIMARY KEY,
+    successes BIGINT NOT NULL DEFAULT 0,
+    failures BIGINT NOT NULL DEFAULT 0,
+    consecutive_failures INT NOT NULL DEFAULT 0,
+    last_success TIMESTAMP,
+    last_failure TIMESTAMP,
+    last_error TEXT,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+-- Trust tier of each source's site (block, low, normal or high), from lib/domains
+ALTER TABLE sources
+    ADD COLUMN IF NOT EXISTS tier TEXT NOT NULL DEFAULT 'normal';
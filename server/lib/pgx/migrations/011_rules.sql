@@ -0,0 +1,6 @@
+-- Tags added by lib/rules, and the names of the rules which fired on each source
+ALTER TABLE sources
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS rule_firings TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS sources_tags_idx ON sources USING GIN (tags);
@@ -0,0 +1,10 @@
+-- Article pages the gmw crawler has already read, so that each is only fetched once.
+-- title_key is the normalized Chinese title, used to skip the same article under another url or on another site.
+CREATE TABLE IF NOT EXISTS crawler_visited (
+    url TEXT PRIMARY KEY,
+    site TEXT NOT NULL,
+    title_key TEXT NOT NULL DEFAULT '',
+    visited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS crawler_visited_title_key_idx ON crawler_visited (title_key) WHERE title_key <> '';
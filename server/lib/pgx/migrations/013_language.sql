@@ -0,0 +1,7 @@
+-- Language of each source (see lib/lang), and for sources in other languages
+-- their original title and text next to the English translation
+ALTER TABLE sources
+    ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT 'en',
+    ADD COLUMN IF NOT EXISTS original_title TEXT,
+    ADD COLUMN IF NOT EXISTS original_text TEXT,
+    ADD COLUMN IF NOT EXISTS english_text TEXT;
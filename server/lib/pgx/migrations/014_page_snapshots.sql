@@ -0,0 +1,11 @@
+-- Text of the pages the labs watcher monitors (model cards, policies, etc.), one row per version,
+-- so that each run can diff a page against its last snapshot
+CREATE TABLE IF NOT EXISTS page_snapshots (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    content TEXT NOT NULL,
+    fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS page_snapshots_url_idx ON page_snapshots (url, fetched_at DESC);
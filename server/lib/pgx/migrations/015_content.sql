@@ -0,0 +1,3 @@
+-- Body of sources which aren't just a link to an article, like collections of tweets
+ALTER TABLE sources
+    ADD COLUMN IF NOT EXISTS content TEXT;
@@ -0,0 +1,18 @@
+-- Posts the reddit source has seen, with their title triage, so that each post is triaged once
+-- and so that post velocity can be compared with a baseline across restarts.
+-- spiked_at is set once a post has been reported as part of a spike, so that it isn't reported twice.
+CREATE TABLE IF NOT EXISTS reddit_posts (
+    id TEXT PRIMARY KEY,
+    subreddit TEXT NOT NULL,
+    region TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL,
+    permalink TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    alarm INTEGER NOT NULL DEFAULT 0,
+    category TEXT NOT NULL DEFAULT 'none',
+    spiked_at TIMESTAMP,
+    seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS reddit_posts_subreddit_idx ON reddit_posts (subreddit, created_at);
+CREATE INDEX IF NOT EXISTS reddit_posts_alarm_idx ON reddit_posts (region, category, created_at) WHERE category <> 'none';
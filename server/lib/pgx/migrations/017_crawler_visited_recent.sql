@@ -0,0 +1,4 @@
+-- The gmw crawler only skips titles it has seen in the last few days (see sources/gmw/mil/visited.go),
+-- so the title index also covers visited_at
+DROP INDEX IF EXISTS crawler_visited_title_key_idx;
+CREATE INDEX IF NOT EXISTS crawler_visited_title_key_idx ON crawler_visited (title_key, visited_at) WHERE title_key <> '';
@@ -2,15 +2,46 @@ package pgx
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"os"
+	"strings"
 
+	"git.nunosempere.com/NunoSempere/news/lib/domains"
+	"git.nunosempere.com/NunoSempere/news/lib/geo"
+	"git.nunosempere.com/NunoSempere/news/lib/lang"
 	"git.nunosempere.com/NunoSempere/news/lib/types"
 	"github.com/jackc/pgx/v5"
 )
 
 // SaveToMainDatabase saves the source to the main sources table
 func SaveToMainDatabase(source types.ExpandedSource) {
+	saveToMainDatabase(source, "ON CONFLICT (link) DO NOTHING")
+}
+
+// Columns ResaveSource overwrites; the link identifies the source, and the rest is what the pipeline produces
+const resavedColumns = `title, date, summary, importance_bool, importance_reasoning,
+            event_type, countries, actors, killed, wounded, affected, pathogen, weapon_system,
+            locations, country_codes, tier, tags, rule_firings,
+            language, original_title, original_text, english_text, content`
+
+// ResaveSource saves the source to the main sources table, replacing any source with the same link,
+// e.g. when reprocessing old articles with a new prompt
+func ResaveSource(source types.ExpandedSource) {
+	saveToMainDatabase(source, "ON CONFLICT (link) DO UPDATE SET ("+resavedColumns+") = ROW("+excludedColumns()+")")
+}
+
+func excludedColumns() string {
+	var columns []string
+	for _, column := range strings.Split(resavedColumns, ",") {
+		columns = append(columns, "EXCLUDED."+strings.TrimSpace(column))
+	}
+	return strings.Join(columns, ", ")
+}
+
+// UpdateImportance records a new importance judgement for a source which is already saved, if it is,
+// e.g. when reprocessing old articles with a new prompt drops one which was kept before
+func UpdateImportance(source types.ExpandedSource) {
 	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_POOL_URL"))
 	if err != nil {
 		log.Printf("Unable to connect to database: %v\n", err)
@@ -18,11 +49,66 @@ func SaveToMainDatabase(source types.ExpandedSource) {
 	}
 	defer conn.Close(context.Background())
 
+	tag, err := conn.Exec(context.Background(), `
+        UPDATE sources SET importance_bool = $2, importance_reasoning = $3
+        WHERE link = $1
+    `, source.Link, source.ImportanceBool, source.ImportanceReasoning)
+	if err != nil {
+		log.Printf("Error updating importance of source: %v\n", err)
+		return
+	}
+	if tag.RowsAffected() > 0 {
+		log.Printf("Updated importance of source: %v\n", source.Title)
+	}
+}
+
+func saveToMainDatabase(source types.ExpandedSource, on_conflict string) {
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_POOL_URL"))
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return
+	}
+	defer conn.Close(context.Background())
+
+	event := source.Event
+	if event == nil {
+		event = &types.StructuredEvent{}
+	}
+
+	tier := source.Tier
+	if tier == "" {
+		tier = string(domains.TierFor(source.Link))
+	}
+	language := source.Language
+	if language == "" {
+		language = lang.English
+	}
+
+	var locations *string
+	var country_codes []string
+	if len(source.Locations) > 0 {
+		locations_json, err := json.Marshal(source.Locations)
+		if err != nil {
+			log.Printf("Error encoding locations: %v\n", err)
+		} else {
+			locations_str := string(locations_json)
+			locations = &locations_str
+		}
+		country_codes = geo.CountryCodes(source.Locations)
+	}
+
 	_, err = conn.Exec(context.Background(), `
-        INSERT INTO sources (title, link, date, summary, importance_bool, importance_reasoning)
-        VALUES ($1, $2, $3, $4, $5, $6)
-        ON CONFLICT (link) DO NOTHING
-    `, source.Title, source.Link, source.Date, source.Summary, source.ImportanceBool, source.ImportanceReasoning)
+        INSERT INTO sources (title, link, date, summary, importance_bool, importance_reasoning,
+            event_type, countries, actors, killed, wounded, affected, pathogen, weapon_system,
+            locations, country_codes, tier, tags, rule_firings,
+            language, original_title, original_text, english_text, content)
+        VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11, $12, NULLIF($13, ''), NULLIF($14, ''),
+            $15::jsonb, $16, $17, COALESCE($18, '{}'::TEXT[]), COALESCE($19, '{}'::TEXT[]),
+            $20, NULLIF($21, ''), NULLIF($22, ''), NULLIF($23, ''), NULLIF($24, ''))
+        `+on_conflict, source.Title, source.Link, source.Date, source.Summary, source.ImportanceBool, source.ImportanceReasoning,
+		event.EventType, event.Countries, event.Actors, event.Killed, event.Wounded, event.Affected, event.Pathogen, event.WeaponSystem,
+		locations, country_codes, tier, source.Tags, source.RuleFirings,
+		language, source.OriginalTitle, source.OriginalText, source.EnglishText, source.Content)
 
 	if err != nil {
 		log.Printf("Error saving source to database: %v\n", err)
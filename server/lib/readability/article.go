@@ -0,0 +1,393 @@
+package readability
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feeds"
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Article is what we get out of a news page, in the manner of Mozilla's Readability.js:
+// score the blocks of the page by how much paragraph-like text they contain, keep the best one and its
+// similarly good siblings, and read the title, byline, date and language from the page's metadata.
+type Article struct {
+	Title        string
+	Byline       string
+	Published    time.Time
+	HasPublished bool
+	Text         string // one paragraph per line
+	Language     string // as declared by the page, e.g. "en" or "zh-CN"; empty if it doesn't say
+}
+
+// Too little text means we probably got a paywall, a cookie banner or an index page
+const minArticleLength = 200
+
+// textLength counts characters, with Chinese, Japanese and Korean ones worth about a word each
+func textLength(s string) int {
+	length := 0
+	for _, r := range s {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			length += 3
+		} else {
+			length++
+		}
+	}
+	return length
+}
+
+var ErrNoArticle = errors.New("no article text found")
+
+var (
+	unlikely_candidates = regexp.MustCompile(`(?i)-ad-|ai2html|banner|breadcrumbs|combx|comment|community|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|related|remark|replies|rss|shoutbox|sidebar|skyscraper|social|sponsor|supplemental|ad-break|agegate|pagination|pager|popup|yom-remote|cookie|newsletter|subscribe|share|promo`)
+	maybe_candidate     = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow|story|text`)
+	positive_class      = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+	negative_class      = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget|byline|author|dateline|caption`)
+	byline_class        = regexp.MustCompile(`(?i)byline|author|dateline|writtenby|p-author`)
+	comma               = regexp.MustCompile(`[,，、]`)
+)
+
+// Elements whose text we score and read paragraphs from, besides divs used as paragraphs
+const paragraphSelector = "p, pre, h2, h3, h4, li, blockquote, td"
+
+// Line breaks are marked with this while we work on the tree, and become paragraph breaks in the text
+const lineBreak = "\u2029"
+
+func classAndID(s *goquery.Selection) string {
+	class, _ := s.Attr("class")
+	id, _ := s.Attr("id")
+	return class + " " + id
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func classWeight(s *goquery.Selection) float64 {
+	weight := 0.0
+	for _, attr := range []string{"class", "id"} {
+		value, ok := s.Attr(attr)
+		if !ok || value == "" {
+			continue
+		}
+		if negative_class.MatchString(value) {
+			weight -= 25
+		}
+		if positive_class.MatchString(value) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+func tagWeight(tag string) float64 {
+	switch tag {
+	case "div", "article", "section", "main":
+		return 5
+	case "pre", "td", "blockquote":
+		return 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		return -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		return -5
+	}
+	return 0
+}
+
+// linkDensity is the fraction of an element's text that is inside links
+func linkDensity(s *goquery.Selection) float64 {
+	text_length := len(normalizeSpace(s.Text()))
+	if text_length == 0 {
+		return 0
+	}
+	link_length := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		link_length += len(normalizeSpace(a.Text()))
+	})
+	return float64(link_length) / float64(text_length)
+}
+
+// removeClutter drops elements that are never part of an article, and those whose class or id says they are boilerplate
+func removeClutter(doc *goquery.Document) {
+	doc.Find("script, style, noscript, iframe, form, nav, aside, footer, svg, button, select, input, template").Remove()
+	doc.Find("*").Each(func(_ int, s *goquery.Selection) {
+		if s.Is("html, body, article, main") {
+			return
+		}
+		match_string := classAndID(s)
+		if role, _ := s.Attr("role"); role == "navigation" || role == "complementary" || role == "dialog" {
+			s.Remove()
+		} else if unlikely_candidates.MatchString(match_string) && !maybe_candidate.MatchString(match_string) {
+			s.Remove()
+		}
+	})
+}
+
+// isParagraphDiv says whether a div is used as a paragraph, i.e. has text but no block-level children
+func isParagraphDiv(s *goquery.Selection) bool {
+	return goquery.NodeName(s) == "div" && s.Children().Filter("div, p, pre, table, ul, ol, blockquote, section, article, h1, h2, h3, h4, h5, h6").Length() == 0
+}
+
+// paragraphs finds the paragraph-like elements in a selection, including the selection itself
+func paragraphs(s *goquery.Selection) *goquery.Selection {
+	divs := s.Find("div").AddSelection(s.Filter("div")).FilterFunction(func(_ int, div *goquery.Selection) bool {
+		return isParagraphDiv(div)
+	})
+	return s.Find(paragraphSelector).AddSelection(s.Filter(paragraphSelector)).AddSelection(divs)
+}
+
+// paragraphText gets the text of a selection, one paragraph per line, without repeating nested blocks
+func paragraphText(s *goquery.Selection) []string {
+	blocks := paragraphs(s)
+	if blocks.Length() == 0 {
+		blocks = s
+	}
+	in_blocks := map[*html.Node]bool{}
+	for _, node := range blocks.Nodes {
+		in_blocks[node] = true
+	}
+
+	var lines []string
+	blocks.Each(func(_ int, block *goquery.Selection) {
+		for parent := block.Nodes[0].Parent; parent != nil; parent = parent.Parent {
+			if in_blocks[parent] {
+				return
+			}
+		}
+		for _, part := range strings.Split(block.Text(), lineBreak) {
+			if line := normalizeSpace(part); line != "" {
+				lines = append(lines, line)
+			}
+		}
+	})
+	return lines
+}
+
+// markLineBreaks replaces <br>s with a marker, as many older sites separate paragraphs with them
+func markLineBreaks(doc *goquery.Document) {
+	doc.Find("br").Each(func(_ int, br *goquery.Selection) {
+		br.ReplaceWithNodes(&html.Node{Type: html.TextNode, Data: lineBreak})
+	})
+}
+
+// grabArticle finds the main content of a page and returns its paragraphs
+func grabArticle(doc *goquery.Document) []string {
+	removeClutter(doc)
+
+	scores := map[*html.Node]float64{}
+	var candidates []*goquery.Selection
+	addScore := func(s *goquery.Selection, score float64) {
+		if s.Length() == 0 || s.Is("html, body") {
+			return
+		}
+		node := s.Nodes[0]
+		if _, ok := scores[node]; !ok {
+			scores[node] = tagWeight(goquery.NodeName(s)) + classWeight(s)
+			candidates = append(candidates, s)
+		}
+		scores[node] += score
+	}
+
+	paragraphs(doc.Selection).Each(func(_ int, s *goquery.Selection) {
+		text := normalizeSpace(strings.ReplaceAll(s.Text(), lineBreak, " "))
+		if len([]rune(text)) < 25 {
+			return
+		}
+		// One point for the paragraph, one per comma, and one per 100 characters, up to 3
+		score := 1 + float64(len(comma.FindAllString(text, -1))) + math.Min(math.Floor(float64(len([]rune(text)))/100), 3)
+		addScore(s.Parent(), score)
+		addScore(s.Parent().Parent(), score/2)
+		addScore(s.Parent().Parent().Parent(), score/3)
+	})
+
+	var top *goquery.Selection
+	top_score := 0.0
+	for _, candidate := range candidates {
+		score := scores[candidate.Nodes[0]] * (1 - linkDensity(candidate))
+		scores[candidate.Nodes[0]] = score
+		if top == nil || score > top_score {
+			top, top_score = candidate, score
+		}
+	}
+	if top == nil {
+		// No paragraphs at all; use the whole body
+		return paragraphText(doc.Find("body"))
+	}
+
+	// Siblings of the top candidate are often part of the article too, e.g. when paragraphs are split by ads
+	var lines []string
+	threshold := math.Max(10, top_score*0.2)
+	top.Parent().Children().Each(func(_ int, sibling *goquery.Selection) {
+		keep := sibling.Nodes[0] == top.Nodes[0]
+		if score, ok := scores[sibling.Nodes[0]]; ok && score >= threshold {
+			keep = true
+		} else if goquery.NodeName(sibling) == "p" {
+			text := normalizeSpace(strings.ReplaceAll(sibling.Text(), lineBreak, " "))
+			density := linkDensity(sibling)
+			keep = (len(text) > 80 && density < 0.25) || (len(text) > 0 && density == 0 && strings.ContainsAny(text, ".。"))
+		}
+		if keep {
+			lines = append(lines, paragraphText(sibling)...)
+		}
+	})
+	return lines
+}
+
+func metaContent(doc *goquery.Document, selectors ...string) string {
+	for _, selector := range selectors {
+		if content, ok := doc.Find(selector).First().Attr("content"); ok && strings.TrimSpace(content) != "" {
+			return strings.TrimSpace(content)
+		}
+	}
+	return ""
+}
+
+// jsonLDField looks for a field like "datePublished" in the page's schema.org metadata
+func jsonLDField(doc *goquery.Document, field string) string {
+	value := ""
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var data any
+		if err := json.Unmarshal([]byte(s.Text()), &data); err != nil {
+			return true
+		}
+		value = findJSONField(data, field)
+		return value == ""
+	})
+	return value
+}
+
+func findJSONField(data any, field string) string {
+	switch v := data.(type) {
+	case map[string]any:
+		if found, ok := v[field]; ok {
+			switch f := found.(type) {
+			case string:
+				return f
+			case map[string]any: // e.g. "author": {"@type": "Person", "name": "..."}
+				if name, ok := f["name"].(string); ok {
+					return name
+				}
+			case []any:
+				if len(f) > 0 {
+					if name, ok := f[0].(map[string]any)["name"].(string); ok {
+						return name
+					}
+				}
+			}
+		}
+		for _, child := range v {
+			if found := findJSONField(child, field); found != "" {
+				return found
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if found := findJSONField(child, field); found != "" {
+				return found
+			}
+		}
+	}
+	return ""
+}
+
+func getTitle(doc *goquery.Document) string {
+	if title := metaContent(doc, `meta[property="og:title"]`, `meta[name="twitter:title"]`); title != "" {
+		return title
+	}
+	title := normalizeSpace(doc.Find("title").First().Text())
+	if h1 := normalizeSpace(doc.Find("h1").First().Text()); h1 != "" && (title == "" || strings.Contains(title, h1)) {
+		// The <title> usually has the site's name appended
+		return h1
+	}
+	return title
+}
+
+func getByline(doc *goquery.Document) string {
+	if byline := metaContent(doc, `meta[name="author"]`, `meta[property="article:author"]`); byline != "" && !strings.HasPrefix(byline, "http") {
+		return byline
+	}
+	if byline := jsonLDField(doc, "author"); byline != "" {
+		return byline
+	}
+	byline := ""
+	doc.Find(`[rel="author"], [itemprop="author"], .byline, .author`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		text := normalizeSpace(s.Text())
+		if text != "" && len(text) < 100 {
+			byline = text
+			return false
+		}
+		return true
+	})
+	if byline == "" {
+		doc.Find("*").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+			if byline_class.MatchString(classAndID(s)) {
+				if text := normalizeSpace(s.Text()); text != "" && len(text) < 100 {
+					byline = text
+					return false
+				}
+			}
+			return true
+		})
+	}
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(byline, "By "), "by "))
+}
+
+func getPublished(doc *goquery.Document) (time.Time, bool) {
+	candidates := []string{
+		metaContent(doc, `meta[property="article:published_time"]`, `meta[name="pubdate"]`, `meta[name="publishdate"]`, `meta[name="publish-date"]`, `meta[name="date"]`, `meta[itemprop="datePublished"]`, `meta[name="dc.date"]`, `meta[name="DC.date.issued"]`),
+		jsonLDField(doc, "datePublished"),
+	}
+	if datetime, ok := doc.Find("time[datetime]").First().Attr("datetime"); ok {
+		candidates = append(candidates, datetime)
+	}
+	for _, candidate := range candidates {
+		if t, err := feeds.ParseDate(candidate); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func getLanguage(doc *goquery.Document) string {
+	if lang, ok := doc.Find("html").First().Attr("lang"); ok && strings.TrimSpace(lang) != "" {
+		return strings.TrimSpace(lang)
+	}
+	if lang := metaContent(doc, `meta[http-equiv="content-language"]`, `meta[http-equiv="Content-Language"]`); lang != "" {
+		return lang
+	}
+	if locale := metaContent(doc, `meta[property="og:locale"]`); locale != "" {
+		return strings.Replace(locale, "_", "-", 1)
+	}
+	return ""
+}
+
+// Parse extracts the article in a page
+func Parse(page []byte) (Article, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return Article{}, err
+	}
+
+	// Metadata first, since finding the article removes bylines, headers and the like
+	article := Article{
+		Title:    getTitle(doc),
+		Byline:   getByline(doc),
+		Language: getLanguage(doc),
+	}
+	article.Published, article.HasPublished = getPublished(doc)
+
+	markLineBreaks(doc)
+	lines := grabArticle(doc)
+	article.Text = strings.Join(lines, "\n")
+	if textLength(article.Text) < minArticleLength {
+		return article, ErrNoArticle
+	}
+	return article, nil
+}
@@ -0,0 +1,152 @@
+package resolve
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// mirrorHealth is how a mirror has been doing. It is shared between sources through the mirror_health table.
+type mirrorHealth struct {
+	Mirror              string
+	Successes           int64
+	Failures            int64
+	ConsecutiveFailures int
+	LastSuccess         time.Time
+	LastFailure         time.Time
+}
+
+const (
+	// A mirror which fails this many times in a row is skipped for a while, and then given another chance
+	maxConsecutiveFailures = 3
+	deadCooldown           = 6 * time.Hour
+	// Other sources report on the same mirrors, so we re-read the table every so often
+	healthRefresh = time.Hour
+)
+
+var (
+	health        = map[string]*mirrorHealth{}
+	health_loaded time.Time
+	health_lock   sync.Mutex
+)
+
+// loadHealth reads the mirror_health table. If there is no database, health is only kept in memory.
+// Must be called with health_lock held.
+func loadHealth() {
+	database_url := os.Getenv("DATABASE_POOL_URL")
+	if database_url == "" || time.Since(health_loaded) < healthRefresh {
+		return
+	}
+	health_loaded = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return
+	}
+	defer conn.Close(context.Background())
+
+	rows, err := conn.Query(ctx, `
+		SELECT mirror, successes, failures, consecutive_failures,
+			COALESCE(last_success, 'epoch'::timestamp), COALESCE(last_failure, 'epoch'::timestamp)
+		FROM mirror_health`)
+	if err != nil {
+		log.Printf("Error loading mirror health: %v\n", err)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var h mirrorHealth
+		if err := rows.Scan(&h.Mirror, &h.Successes, &h.Failures, &h.ConsecutiveFailures, &h.LastSuccess, &h.LastFailure); err != nil {
+			log.Printf("Error reading mirror health: %v\n", err)
+			return
+		}
+		health[h.Mirror] = &h
+	}
+}
+
+func isDead(mirror string) bool {
+	health_lock.Lock()
+	defer health_lock.Unlock()
+	loadHealth()
+	h, ok := health[mirror]
+	return ok && h.ConsecutiveFailures >= maxConsecutiveFailures && time.Since(h.LastFailure) < deadCooldown
+}
+
+// report records whether a mirror worked
+func report(mirror string, err error) {
+	if mirror == "" {
+		return
+	}
+	health_lock.Lock()
+	h, ok := health[mirror]
+	if !ok {
+		h = &mirrorHealth{Mirror: mirror}
+		health[mirror] = h
+	}
+	if err == nil {
+		h.Successes++
+		h.ConsecutiveFailures = 0
+		h.LastSuccess = time.Now()
+	} else {
+		h.Failures++
+		h.ConsecutiveFailures++
+		h.LastFailure = time.Now()
+		if h.ConsecutiveFailures == maxConsecutiveFailures {
+			log.Printf("Mirror %s failed %d times in a row, skipping it for %v: %v", mirror, h.ConsecutiveFailures, deadCooldown, err)
+		}
+	}
+	health_lock.Unlock()
+
+	saveHealth(mirror, err)
+}
+
+func saveHealth(mirror string, fetch_err error) {
+	database_url := os.Getenv("DATABASE_POOL_URL")
+	if database_url == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return
+	}
+	defer conn.Close(context.Background())
+
+	successes, failures := 1, 0
+	var last_success, last_failure *time.Time
+	var last_error *string
+	now := time.Now()
+	if fetch_err != nil {
+		successes, failures = 0, 1
+		last_failure = &now
+		message := fetch_err.Error()
+		last_error = &message
+	} else {
+		last_success = &now
+	}
+
+	_, err = conn.Exec(ctx, `
+		INSERT INTO mirror_health (mirror, successes, failures, consecutive_failures, last_success, last_failure, last_error)
+		VALUES ($1, $2, $3, $3, $4, $5, $6)
+		ON CONFLICT (mirror) DO UPDATE SET
+			successes = mirror_health.successes + EXCLUDED.successes,
+			failures = mirror_health.failures + EXCLUDED.failures,
+			consecutive_failures = CASE WHEN EXCLUDED.failures > 0 THEN mirror_health.consecutive_failures + 1 ELSE 0 END,
+			last_success = COALESCE(EXCLUDED.last_success, mirror_health.last_success),
+			last_failure = COALESCE(EXCLUDED.last_failure, mirror_health.last_failure),
+			last_error = COALESCE(EXCLUDED.last_error, mirror_health.last_error),
+			updated_at = CURRENT_TIMESTAMP
+	`, mirror, successes, failures, last_success, last_failure, last_error)
+	if err != nil {
+		log.Printf("Error saving mirror health: %v\n", err)
+	}
+}
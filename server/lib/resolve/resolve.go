@@ -0,0 +1,322 @@
+package resolve
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feeds"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"github.com/PuerkitoBio/goquery"
+)
+
+/* Some sites are paywalled or block us, and the open source frontends that mirror them come and go.
+So for each such site we keep a chain of ways to get a readable copy of a page, tried in order,
+and we skip mirrors which have been failing (see health.go). */
+
+// Resolver is one way of getting a readable copy of a page
+type Resolver struct {
+	Kind     string `json:"kind"`               // "direct", "frontend", "rewrite", "archive" or "search"
+	Name     string `json:"name,omitempty"`     // what its health is recorded as; defaults to the mirror's host
+	Host     string `json:"host,omitempty"`     // frontend: replaces the link's host, e.g. neuters.de for reuters.com
+	Template string `json:"template,omitempty"` // rewrite: url with {scheme}, {host}, {path}, {query} and {url}, e.g. for AMP or print versions. search: RSS search url with {title}
+}
+
+// Chain is the resolvers for some domains
+type Chain struct {
+	Name      string     `json:"name"`
+	Domains   []string   `json:"domains"` // also matches subdomains
+	Resolvers []Resolver `json:"resolvers"`
+}
+
+const archiveMirror = "web.archive.org"
+
+var ErrUnresolved = errors.New("no resolver worked")
+
+//go:embed mirrors.json
+var default_chains []byte
+
+var (
+	chains      []Chain
+	chains_lock sync.RWMutex
+)
+
+func init() {
+	parsed, err := parseChains(default_chains)
+	if err != nil {
+		log.Fatalf("Error parsing embedded mirrors: %v", err)
+	}
+	chains = parsed
+}
+
+func parseChains(data []byte) ([]Chain, error) {
+	var parsed []Chain
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+	for i, chain := range parsed {
+		if len(chain.Domains) == 0 || len(chain.Resolvers) == 0 {
+			return nil, fmt.Errorf("chain #%d (%q) needs at least a domain and a resolver", i+1, chain.Name)
+		}
+		for _, resolver := range chain.Resolvers {
+			switch {
+			case resolver.Kind == "frontend" && resolver.Host == "",
+				resolver.Kind == "rewrite" && (resolver.Template == "" || resolver.Name == ""),
+				resolver.Kind == "search" && resolver.Template == "":
+				return nil, fmt.Errorf("chain %q has an incomplete %s resolver", chain.Name, resolver.Kind)
+			case resolver.Kind != "direct" && resolver.Kind != "frontend" && resolver.Kind != "rewrite" && resolver.Kind != "archive" && resolver.Kind != "search":
+				return nil, fmt.Errorf("chain %q has an unknown resolver kind %q", chain.Name, resolver.Kind)
+			}
+		}
+	}
+	return parsed, nil
+}
+
+// LoadMirrors replaces the embedded chains with those in a file
+func LoadMirrors(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	parsed, err := parseChains(data)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	chains_lock.Lock()
+	chains = parsed
+	chains_lock.Unlock()
+	return nil
+}
+
+// ChainFor finds the chain for a link's domain
+func ChainFor(link string) (Chain, bool) {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return Chain{}, false
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+
+	chains_lock.RLock()
+	defer chains_lock.RUnlock()
+	for _, chain := range chains {
+		for _, domain := range chain.Domains {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return chain, true
+			}
+		}
+	}
+	return Chain{}, false
+}
+
+// mirror is the name health is recorded under; direct fetches aren't tracked
+func (r Resolver) mirror() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	switch r.Kind {
+	case "frontend":
+		return r.Host
+	case "archive":
+		return archiveMirror
+	case "search":
+		if parsed, err := url.Parse(r.Template); err == nil {
+			return parsed.Hostname()
+		}
+	}
+	return ""
+}
+
+// candidate gets the url to try for a link. It is empty if the resolver has nothing for this link, e.g. no archived snapshot.
+func (r Resolver) candidate(link *url.URL, title string) (string, error) {
+	switch r.Kind {
+	case "direct":
+		return link.String(), nil
+	case "frontend":
+		mirrored := *link
+		mirrored.Host = r.Host
+		return mirrored.String(), nil
+	case "rewrite":
+		return strings.NewReplacer(
+			"{scheme}", link.Scheme,
+			"{host}", link.Host,
+			"{path}", link.EscapedPath(),
+			"{query}", link.RawQuery,
+			"{url}", url.QueryEscape(link.String()),
+		).Replace(r.Template), nil
+	case "archive":
+		return archivedCopy(link.String())
+	case "search":
+		return searchSameStory(r.Template, link.String(), title)
+	}
+	return "", fmt.Errorf("unknown resolver kind %q", r.Kind)
+}
+
+type waybackResponse struct {
+	ArchivedSnapshots struct {
+		Closest struct {
+			Available bool   `json:"available"`
+			URL       string `json:"url"`
+			Status    string `json:"status"`
+		} `json:"closest"`
+	} `json:"archived_snapshots"`
+}
+
+// archivedCopy finds the latest snapshot of a page in the Wayback Machine
+func archivedCopy(link string) (string, error) {
+	data, err := web.Get("https://archive.org/wayback/available?url=" + url.QueryEscape(link))
+	if err != nil {
+		return "", err
+	}
+	var response waybackResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return "", fmt.Errorf("parsing wayback response: %w", err)
+	}
+	closest := response.ArchivedSnapshots.Closest
+	if !closest.Available || closest.Status != "200" {
+		log.Printf("No archived copy of %s", link)
+		return "", nil
+	}
+	return strings.Replace(closest.URL, "http://", "https://", 1), nil
+}
+
+// pageTitle gets the headline of a page, which paywalls usually still show
+func pageTitle(link string) string {
+	page, err := web.Get(link)
+	if err != nil {
+		return ""
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return ""
+	}
+	if title, ok := doc.Find("meta[property='og:title']").Attr("content"); ok && strings.TrimSpace(title) != "" {
+		return strings.TrimSpace(title)
+	}
+	return strings.TrimSpace(doc.Find("title").First().Text())
+}
+
+// titleWords are the words of a title worth comparing
+func titleWords(title string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) > 3 {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// sameHeadline is true if most of the words of the shorter title are in the other one
+func sameHeadline(a string, b string) bool {
+	words_a, words_b := titleWords(a), titleWords(b)
+	if len(words_a) == 0 || len(words_b) == 0 {
+		return false
+	}
+	shared := 0
+	for word := range words_a {
+		if words_b[word] {
+			shared++
+		}
+	}
+	return float64(shared) >= 0.6*float64(min(len(words_a), len(words_b)))
+}
+
+// searchSameStory looks for the headline on an outlet which doesn't need resolving
+func searchSameStory(template string, link string, title string) (string, error) {
+	if title == "" {
+		title = pageTitle(link)
+	}
+	if title == "" {
+		return "", nil
+	}
+	data, err := web.Get(strings.ReplaceAll(template, "{title}", url.QueryEscape(title)))
+	if err != nil {
+		return "", err
+	}
+	items, err := feeds.Parse(data)
+	if err != nil {
+		return "", fmt.Errorf("parsing search results: %w", err)
+	}
+	for _, item := range items {
+		found := item.Link
+		// Bing wraps results in a redirect
+		if parsed, err := url.Parse(found); err == nil && strings.HasSuffix(parsed.Hostname(), "bing.com") && parsed.Query().Get("url") != "" {
+			found = parsed.Query().Get("url")
+		}
+		if _, needs_resolving := ChainFor(found); needs_resolving {
+			continue
+		}
+		if sameHeadline(title, item.Title) {
+			log.Printf("Found %q at %s", item.Title, found)
+			return found, nil
+		}
+	}
+	log.Printf("Found no free copy of %q", title)
+	return "", nil
+}
+
+// fetchError is err if getting the page failed. Other errors, like a paywalled page with no article in it,
+// say nothing about whether the mirror works, which is then counted as working since it answered.
+func fetchError(err error) error {
+	var fetch_err *web.FetchError
+	if errors.As(err, &fetch_err) {
+		return err
+	}
+	return nil
+}
+
+// Resolve tries the chain for a link's domain in order, skipping dead mirrors, until try succeeds on one of its urls,
+// and returns that url. Links without a chain are tried as they are. The title is used to search for the same story
+// elsewhere; if it is empty, it is taken from the page.
+func Resolve(link string, title string, try func(candidate string) error) (string, error) {
+	chain, ok := ChainFor(link)
+	if !ok {
+		return link, try(link)
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+
+	var errs []error
+	for _, resolver := range chain.Resolvers {
+		mirror := resolver.mirror()
+		if mirror != "" && isDead(mirror) {
+			log.Printf("Skipping %s, which has been failing", mirror)
+			continue
+		}
+
+		candidate, err := resolver.candidate(parsed, title)
+		if err != nil || resolver.Kind == "search" {
+			// For searches, the health of the mirror is whether the search works, not whether the outlet it finds does
+			report(mirror, err)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", resolver.Kind, err))
+			continue
+		} else if candidate == "" {
+			continue
+		}
+
+		log.Printf("Trying %s for %s", candidate, link)
+		err = try(candidate)
+		if mirror != "" && resolver.Kind != "search" {
+			report(mirror, fetchError(err))
+		}
+		if err == nil {
+			return candidate, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", candidate, err))
+	}
+	return "", fmt.Errorf("%w for %s: %w", ErrUnresolved, link, errors.Join(errs...))
+}
@@ -0,0 +1,273 @@
+package rules
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/domains"
+	"git.nunosempere.com/NunoSempere/news/lib/outbound"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+/* Keyword rules which override or add to the LLM's importance judgement, for every source. A rule fires
+when all of its "all" conditions match, at least one of its "any" conditions (if it has any), and none of
+its "none" conditions. It can then keep or drop the source, set its trust tier, tag it, or send an alert.
+The rules are in rules.json, and can be changed without recompiling by pointing RULES_FILE to a file in
+the same format, which is re-read when it changes. */
+
+// Condition matches one field of a source: title, summary, origin, domain (e.g. "bbc.co.uk") or link.
+// Contains and Equals are case-insensitive; Regex isn't, unless it starts with (?i).
+type Condition struct {
+	Field    string `json:"field"`
+	Contains string `json:"contains,omitempty"`
+	Equals   string `json:"equals,omitempty"`
+	Regex    string `json:"regex,omitempty"`
+	regex    *regexp.Regexp
+}
+
+type Rule struct {
+	Name  string      `json:"name"`
+	All   []Condition `json:"all,omitempty"`
+	Any   []Condition `json:"any,omitempty"`
+	None  []Condition `json:"none,omitempty"`
+	Keep  bool        `json:"keep,omitempty"`  // keep the source even if the LLM thought it unimportant
+	Drop  bool        `json:"drop,omitempty"`  // drop it even if the LLM thought it important; wins over keep
+	Tier  string      `json:"tier,omitempty"`  // see lib/domains; "block" drops it
+	Tags  []string    `json:"tags,omitempty"`  // saved with the source
+	Alert string      `json:"alert,omitempty"` // channel to send kept sources to, see alert_channels
+}
+
+// Where alerts go. A rule naming another channel is rejected when the rules are loaded.
+var alert_channels = map[string]func(source types.ExpandedSource, rule string) error{
+	"email": func(source types.ExpandedSource, rule string) error {
+		body := fmt.Sprintf("<p>Rule <b>%s</b> fired on:</p><p><a href=\"%s\">%s</a></p><p>%s</p>",
+			html.EscapeString(rule), html.EscapeString(source.Link), html.EscapeString(source.Title), html.EscapeString(source.Summary))
+		_, err := outbound.SendPostmarkEmail(body)
+		return err
+	},
+}
+
+//go:embed rules.json
+var default_rules []byte
+
+const rulesCheckInterval = time.Minute
+
+var (
+	rules         []Rule
+	rules_path    string
+	rules_mtime   time.Time
+	rules_checked time.Time
+	rules_lock    sync.Mutex
+)
+
+func init() {
+	parsed, err := parseRules(default_rules)
+	if err != nil {
+		log.Fatalf("Error parsing embedded rules: %v", err)
+	}
+	rules = parsed
+}
+
+func parseCondition(c *Condition) error {
+	switch c.Field {
+	case "title", "summary", "origin", "domain", "link":
+	default:
+		return fmt.Errorf("unknown field %q", c.Field)
+	}
+	set := 0
+	for _, s := range []string{c.Contains, c.Equals, c.Regex} {
+		if s != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("condition on %s needs exactly one of contains, equals or regex", c.Field)
+	}
+	if c.Regex != "" {
+		regex, err := regexp.Compile(c.Regex)
+		if err != nil {
+			return err
+		}
+		c.regex = regex
+	}
+	return nil
+}
+
+func parseRules(data []byte) ([]Rule, error) {
+	var parsed []Rule
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+	for i := range parsed {
+		rule := &parsed[i]
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule #%d has no name", i+1)
+		}
+		if len(rule.All)+len(rule.Any) == 0 {
+			return nil, fmt.Errorf("rule %s has no all or any conditions", rule.Name)
+		}
+		for _, conditions := range [][]Condition{rule.All, rule.Any, rule.None} {
+			for j := range conditions {
+				if err := parseCondition(&conditions[j]); err != nil {
+					return nil, fmt.Errorf("rule %s: %v", rule.Name, err)
+				}
+			}
+		}
+		switch domains.Tier(rule.Tier) {
+		case "", domains.TierBlock, domains.TierLow, domains.TierNormal, domains.TierHigh:
+		default:
+			return nil, fmt.Errorf("rule %s: unknown tier %q", rule.Name, rule.Tier)
+		}
+		if _, ok := alert_channels[rule.Alert]; rule.Alert != "" && !ok {
+			return nil, fmt.Errorf("rule %s: unknown alert channel %q", rule.Name, rule.Alert)
+		}
+		if !rule.Keep && !rule.Drop && rule.Tier == "" && len(rule.Tags) == 0 && rule.Alert == "" {
+			return nil, fmt.Errorf("rule %s does nothing", rule.Name)
+		}
+	}
+	return parsed, nil
+}
+
+// reloadIfChanged re-reads RULES_FILE if it has changed. Must be called with rules_lock held.
+func reloadIfChanged() {
+	path := os.Getenv("RULES_FILE")
+	if path == "" || path == rules_path && time.Since(rules_checked) < rulesCheckInterval {
+		return
+	}
+	rules_checked = time.Now()
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Printf("Error reading rules %s, keeping the current ones: %v", path, err)
+		return
+	}
+	if path == rules_path && info.ModTime().Equal(rules_mtime) {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Error reading rules %s, keeping the current ones: %v", path, err)
+		return
+	}
+	parsed, err := parseRules(data)
+	if err != nil {
+		log.Printf("Error parsing rules %s, keeping the current ones: %v", path, err)
+		return
+	}
+	rules, rules_path, rules_mtime = parsed, path, info.ModTime()
+	log.Printf("Loaded %d rules from %s", len(rules), path)
+}
+
+func field(source types.ExpandedSource, name string) string {
+	switch name {
+	case "title":
+		return source.Title
+	case "summary":
+		return source.Summary
+	case "origin":
+		return source.Origin
+	case "domain":
+		return domains.Domain(source.Link)
+	case "link":
+		return source.Link
+	}
+	return ""
+}
+
+func (c Condition) matches(source types.ExpandedSource) bool {
+	value := field(source, c.Field)
+	switch {
+	case c.regex != nil:
+		return c.regex.MatchString(value)
+	case c.Equals != "":
+		return strings.EqualFold(value, c.Equals)
+	default:
+		return strings.Contains(strings.ToLower(value), strings.ToLower(c.Contains))
+	}
+}
+
+func (r Rule) matches(source types.ExpandedSource) bool {
+	for _, c := range r.All {
+		if !c.matches(source) {
+			return false
+		}
+	}
+	for _, c := range r.None {
+		if c.matches(source) {
+			return false
+		}
+	}
+	if len(r.Any) == 0 {
+		return true
+	}
+	for _, c := range r.Any {
+		if c.matches(source) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply runs the rules on a source after the importance check, which decided ok,
+// and returns whether to keep it. Each rule which fires is recorded in source.RuleFirings.
+func Apply(source types.ExpandedSource, ok bool) (types.ExpandedSource, bool) {
+	rules_lock.Lock()
+	reloadIfChanged()
+	current := rules
+	rules_lock.Unlock()
+
+	var kept_by []string
+	var drop bool
+	var alerts []Rule
+	for _, rule := range current {
+		if !rule.matches(source) {
+			continue
+		}
+		log.Printf("Rule %s fired", rule.Name)
+		source.RuleFirings = append(source.RuleFirings, rule.Name)
+		if rule.Keep {
+			kept_by = append(kept_by, rule.Name)
+		}
+		drop = drop || rule.Drop || rule.Tier == string(domains.TierBlock)
+		if rule.Tier != "" {
+			source.Tier = rule.Tier
+		}
+		for _, tag := range rule.Tags {
+			if !slices.Contains(source.Tags, tag) {
+				source.Tags = append(source.Tags, tag)
+			}
+		}
+		if rule.Alert != "" {
+			alerts = append(alerts, rule)
+		}
+	}
+
+	switch {
+	case drop:
+		if ok {
+			log.Printf("Filtered because: dropped by rule")
+		}
+		source.ImportanceBool, ok = false, false
+	case len(kept_by) > 0 && !ok:
+		log.Printf("Kept by rule, although the importance check didn't keep it")
+		source.ImportanceBool, ok = true, true
+		source.ImportanceReasoning = strings.TrimSpace("Kept by rule " + strings.Join(kept_by, ", ") + ". " + source.ImportanceReasoning)
+	}
+
+	if ok {
+		for _, rule := range alerts {
+			if err := alert_channels[rule.Alert](source, rule.Name); err != nil {
+				log.Printf("Error sending %s alert for rule %s: %v", rule.Alert, rule.Name, err)
+			}
+		}
+	}
+	return source, ok
+}
@@ -0,0 +1,161 @@
+package stories
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	DeltaEscalation   = "escalation"
+	DeltaDeescalation = "de-escalation"
+	DeltaNoChange     = "no-change"
+
+	// Stories without new events for this long are no longer offered to the classifier
+	openStoryWindow = 60 * 24 * time.Hour
+	maxOpenStories  = 50
+	defaultTimeout  = 10 * time.Second
+)
+
+type Story struct {
+	ID          int
+	Name        string
+	LatestState string
+	UpdatedAt   time.Time
+}
+
+type Event struct {
+	StoryID        int
+	Link           string
+	Title          string
+	Date           time.Time
+	Delta          string
+	DeltaReasoning string
+}
+
+func IsValidDelta(delta string) bool {
+	return delta == DeltaEscalation || delta == DeltaDeescalation || delta == DeltaNoChange
+}
+
+// GetOpenStories returns the most recently updated stories, which are the candidates a new article can be linked to
+func GetOpenStories(database_url string) ([]Story, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return nil, err
+	}
+	defer conn.Close(context.Background())
+
+	rows, err := conn.Query(ctx, `
+		SELECT id, name, COALESCE(latest_state, ''), updated_at
+		FROM stories
+		WHERE updated_at > $1
+		ORDER BY updated_at DESC
+		LIMIT $2
+	`, time.Now().Add(-openStoryWindow), maxOpenStories)
+	if err != nil {
+		log.Printf("Error querying open stories: %v\n", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stories []Story
+	for rows.Next() {
+		var s Story
+		if err := rows.Scan(&s.ID, &s.Name, &s.LatestState, &s.UpdatedAt); err != nil {
+			log.Printf("Error scanning story: %v\n", err)
+			return nil, err
+		}
+		stories = append(stories, s)
+	}
+	return stories, rows.Err()
+}
+
+// CreateStory creates a new story, or returns the id of an existing story with the same name
+func CreateStory(database_url string, name string, state string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return 0, err
+	}
+	defer conn.Close(context.Background())
+
+	var id int
+	err = conn.QueryRow(ctx, `
+		INSERT INTO stories (name, latest_state)
+		VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET updated_at = CURRENT_TIMESTAMP
+		RETURNING id
+	`, name, state).Scan(&id)
+	if err != nil {
+		log.Printf("Error creating story: %v\n", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+// AddEvent appends an article to a story's timeline and, if it is the story's latest event, moves the story's state forward
+func AddEvent(database_url string, event Event, new_state string) error {
+	if !IsValidDelta(event.Delta) {
+		return fmt.Errorf("invalid story delta: %q", event.Delta)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v\n", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO story_events (story_id, link, title, date, delta, delta_reasoning)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (story_id, link) DO NOTHING
+	`, event.StoryID, event.Link, event.Title, event.Date, event.Delta, event.DeltaReasoning)
+	if err != nil {
+		log.Printf("Error saving story event: %v\n", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		// Already in the timeline, e.g. an article processed again; it has moved the story already
+		return nil
+	}
+
+	// Only the story's latest event moves its state, so that an older article doesn't bring back a past state
+	_, err = tx.Exec(ctx, `
+		UPDATE stories
+		SET latest_state = COALESCE(NULLIF($1, ''), latest_state),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		  AND NOT EXISTS (SELECT 1 FROM story_events WHERE story_id = $2 AND date > $3)
+	`, new_state, event.StoryID, event.Date)
+	if err != nil {
+		log.Printf("Error updating story state: %v\n", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing story event: %v\n", err)
+		return err
+	}
+	return nil
+}
@@ -5,10 +5,34 @@ import (
 )
 
 type Source struct {
-	Title  string
-	Link   string
-	Date   time.Time
-	Origin string
+	Title     string
+	Link      string
+	Date      time.Time
+	Origin    string
+	Content   string           // body, for sources which aren't just a link to an article, like collections of tweets
+	Event     *StructuredEvent // prefilled by sources which already know some fields, like GDELT
+	Locations []Location       // likewise, e.g. GKG location fields
+}
+
+// Location is a place a source talks about, as resolved by lib/geo or given by the source itself.
+type Location struct {
+	Name        string  `json:"name"`
+	CountryCode string  `json:"country_code"` // ISO 3166-1 alpha-2, empty for e.g. international waters
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+}
+
+// StructuredEvent holds the fields extracted from a kept article.
+// Counts are nil when the article doesn't mention them.
+type StructuredEvent struct {
+	Countries    []string
+	Actors       []string
+	EventType    string
+	Killed       *int
+	Wounded      *int
+	Affected     *int
+	Pathogen     string
+	WeaponSystem string
 }
 
 type CacheChecker func(string) (bool, error)
@@ -33,6 +57,17 @@ type ExpandedSource struct {
 	ImportanceBool      bool
 	ImportanceReasoning string
 	Origin              string
+	Content             string // see Source.Content
+	Event               *StructuredEvent
+	Locations           []Location
+	Tier                string   // trust tier of the site, see lib/domains
+	Tags                []string // added by lib/rules
+	RuleFirings         []string // names of the rules in lib/rules which fired
+	Language            string   // ISO 639-1 code, see lib/lang; empty means English
+	OriginalTitle       string   // for sources in other languages, before translation
+	OriginalText        string
+	EnglishText         string // full translation of OriginalText, only made for sources which pass the importance check
+	Failed              bool   // dropped because an LLM call or a fetch failed in a way that may pass, rather than on its merits, so worth trying again
 }
 
 type Filter func(ExpandedSource) (ExpandedSource, bool)
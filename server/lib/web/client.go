@@ -0,0 +1,302 @@
+package web
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/* A shared, polite http client: every fetcher should go through it, so that we don't hammer a site
+from several sources at once, and so that a slow or broken site can't hang a source. */
+
+// Config of a Client; zero fields get the defaults of DefaultConfig
+type Config struct {
+	Timeout        time.Duration // for the whole request, including reading the body
+	MaxBodySize    int64         // bytes, after decompression; -1 for no limit
+	MaxRetries     int           // on network errors, 5xx and 429; -1 for none
+	RequestsPerSec float64       // per host
+	Burst          int           // requests a host can get at once before being rate limited
+	RespectRobots  bool          // check robots.txt before fetching; for crawlers, rather than for following links
+	UserAgent      string
+	Mode           string // ModeRecord, ModeReplay or ModeCache to use fixtures instead of the network; WEB_MODE or SetMode if empty, see replay.go
+	FixturesDir    string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:        30 * time.Second,
+		MaxBodySize:    10 << 20,
+		MaxRetries:     2,
+		RequestsPerSec: 1,
+		Burst:          3,
+		RespectRobots:  false,
+		// Some sites, e.g. government ones, refuse requests that don't look like they come from a browser
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	}
+}
+
+type Client struct {
+	config Config
+	http   *http.Client
+
+	lock     sync.Mutex
+	limiters map[string]*tokenBucket
+	robots   map[string]*robotsRules
+}
+
+func NewClient(config Config) *Client {
+	defaults := DefaultConfig()
+	if config.Timeout == 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.MaxBodySize == 0 {
+		config.MaxBodySize = defaults.MaxBodySize
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = defaults.MaxRetries
+	}
+	if config.RequestsPerSec == 0 {
+		config.RequestsPerSec = defaults.RequestsPerSec
+	}
+	if config.Burst == 0 {
+		config.Burst = defaults.Burst
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = defaults.UserAgent
+	}
+	return &Client{
+		config:   config,
+		http:     &http.Client{Timeout: config.Timeout, Transport: newTransport(config.Mode, config.FixturesDir, nil)},
+		limiters: map[string]*tokenBucket{},
+		robots:   map[string]*robotsRules{},
+	}
+}
+
+func (c *Client) mode() string {
+	if c.config.Mode != "" {
+		return c.config.Mode
+	}
+	mode, _ := defaultMode()
+	return mode
+}
+
+// DefaultClient is used by Get, and by the fetchers which don't need their own settings
+var DefaultClient = NewClient(DefaultConfig())
+
+var (
+	ErrTooLarge   = errors.New("response body too large")
+	ErrDisallowed = errors.New("disallowed by robots.txt")
+)
+
+// FetchError says which url failed and how; StatusCode is 0 if we didn't get a response
+type FetchError struct {
+	URL        string
+	StatusCode int
+	Attempts   int
+	Err        error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("fetching %s: %v (after %d attempt(s))", e.URL, e.Err, e.Attempts)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// IsTemporary is true for fetch errors worth trying again later: network errors, 5xx, 429 and bodies cut short.
+// A 404, a request we never sent (e.g. disallowed by robots.txt) or a body that is too large will be the same next time.
+func IsTemporary(err error) bool {
+	var fetch_err *FetchError
+	if !errors.As(err, &fetch_err) || fetch_err.Attempts == 0 || errors.Is(err, ErrTooLarge) || errors.Is(err, ErrNotRecorded) {
+		return false
+	}
+	return fetch_err.StatusCode == 0 || isRetryable(fetch_err.StatusCode) || fetch_err.StatusCode/100 == 2
+}
+
+// tokenBucket allows Burst requests at once, refilled at RequestsPerSec
+type tokenBucket struct {
+	lock   sync.Mutex
+	tokens float64
+	rate   float64
+	burst  float64
+	last   time.Time
+}
+
+func (b *tokenBucket) wait() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		time.Sleep(delay)
+		b.last = time.Now()
+		b.tokens = 1
+	}
+	b.tokens--
+}
+
+func (c *Client) limiter(host string) *tokenBucket {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	bucket, ok := c.limiters[host]
+	if !ok {
+		burst := float64(c.config.Burst)
+		bucket = &tokenBucket{tokens: burst, rate: c.config.RequestsPerSec, burst: burst, last: time.Now()}
+		c.limiters[host] = bucket
+	}
+	return bucket
+}
+
+func isRetryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryDelay is exponential, unless the server tells us how long to wait
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	delay := time.Duration(1<<attempt) * time.Second
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			delay = time.Duration(seconds) * time.Second
+		}
+	}
+	return min(delay, time.Minute)
+}
+
+// limitedBody decompresses gzip if needed, caps the size of a body and closes the underlying one
+type limitedBody struct {
+	io.Reader
+	closers []io.Closer
+	limit   int64
+	read    int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	b.read += int64(n)
+	if b.limit >= 0 && b.read > b.limit {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	var err error
+	for _, closer := range b.closers {
+		err = errors.Join(err, closer.Close())
+	}
+	return err
+}
+
+func (c *Client) wrapBody(resp *http.Response) error {
+	body := &limitedBody{Reader: resp.Body, closers: []io.Closer{resp.Body}, limit: c.config.MaxBodySize}
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return fmt.Errorf("reading gzip body: %w", err)
+		}
+		body.Reader = gz
+		body.closers = append([]io.Closer{gz}, body.closers...)
+		resp.Header.Del("Content-Encoding")
+		resp.ContentLength = -1
+	}
+	if c.config.MaxBodySize >= 0 && resp.ContentLength > c.config.MaxBodySize {
+		return ErrTooLarge
+	}
+	resp.Body = body
+	return nil
+}
+
+// Do sends a GET request with browser-like headers, waiting for the host's rate limit and retrying
+// on network errors, 5xx and 429. Non-2xx responses other than 304 are returned as a *FetchError.
+// The caller must close the body, which is decompressed and limited to the client's MaxBodySize.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.config.UserAgent)
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
+	}
+	if req.Header.Get("Accept-Language") == "" {
+		req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	}
+	// Set explicitly, so that we can also cap the size of compressed responses
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	if c.config.RespectRobots && !c.allowedByRobots(req.URL) {
+		return nil, &FetchError{URL: req.URL.String(), Err: ErrDisallowed}
+	}
+
+	bucket := c.limiter(req.URL.Host)
+	attempts := 0
+	for {
+		attempts++
+		if c.mode() != ModeReplay {
+			bucket.wait()
+		}
+		resp, err := c.http.Do(req)
+
+		retry := err != nil && !errors.Is(err, ErrNotRecorded) || err == nil && isRetryable(resp.StatusCode)
+		if retry && attempts <= c.config.MaxRetries {
+			delay := retryDelay(resp, attempts-1)
+			if err != nil {
+				log.Printf("Error fetching %s, retrying in %v: %v", req.URL, delay, err)
+			} else {
+				log.Printf("Got status %d for %s, retrying in %v", resp.StatusCode, req.URL, delay)
+				resp.Body.Close()
+			}
+			time.Sleep(delay)
+			continue
+		}
+
+		if err != nil {
+			log.Printf("GET error: %v", err)
+			return nil, &FetchError{URL: req.URL.String(), Attempts: attempts, Err: err}
+		}
+		if resp.StatusCode == http.StatusNotModified {
+			return resp, nil
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			resp.Body.Close()
+			log.Printf("Status error: %v", resp.StatusCode)
+			return nil, &FetchError{URL: req.URL.String(), StatusCode: resp.StatusCode, Attempts: attempts, Err: fmt.Errorf("http status %d", resp.StatusCode)}
+		}
+		if err := c.wrapBody(resp); err != nil {
+			resp.Body.Close()
+			return nil, &FetchError{URL: req.URL.String(), StatusCode: resp.StatusCode, Attempts: attempts, Err: err}
+		}
+		return resp, nil
+	}
+}
+
+// Open is Do for a plain GET, for callers who want to stream the body
+func (c *Client) Open(link string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", link, nil)
+	if err != nil {
+		return nil, &FetchError{URL: link, Err: err}
+	}
+	return c.Do(req)
+}
+
+// Get fetches a url and reads its body
+func (c *Client) Get(link string) ([]byte, error) {
+	resp, err := c.Open(link)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading body: %v", err)
+		return nil, &FetchError{URL: link, StatusCode: resp.StatusCode, Attempts: 1, Err: err}
+	}
+	return body, nil
+}
@@ -0,0 +1,342 @@
+package web
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* Record and replay http responses, so that a source can be developed and checked without hitting live sites.
+Set WEB_MODE to
+  - "record", to save every response to WEB_FIXTURES (fixtures/web by default),
+  - "replay", to serve responses from there, and fail on anything that wasn't recorded,
+  - "cache", to serve recorded responses and record the rest.
+Each response is saved as a .json file with its url, status and headers, next to a .body file with its decompressed body.
+This covers pages, feeds and LLM calls, but not the database, which sources still write to. */
+
+const (
+	ModeLive   = ""
+	ModeRecord = "record"
+	ModeReplay = "replay"
+	ModeCache  = "cache"
+)
+
+const defaultFixturesDir = "fixtures/web"
+
+var ErrNotRecorded = errors.New("no recorded response")
+
+type recording struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Recorded   time.Time   `json:"recorded"`
+}
+
+type replayTransport struct {
+	mode string // empty to follow SetMode, or WEB_MODE
+	dir  string
+	live http.RoundTripper
+}
+
+var (
+	default_mode, default_dir = modeFromEnv()
+	default_lock              sync.RWMutex
+)
+
+func modeFromEnv() (string, string) {
+	mode := os.Getenv("WEB_MODE")
+	dir := os.Getenv("WEB_FIXTURES")
+	if dir == "" {
+		dir = defaultFixturesDir
+	}
+	switch mode {
+	case ModeLive:
+	case ModeRecord, ModeReplay, ModeCache:
+		log.Printf("Web mode: %s, fixtures in %s", mode, dir)
+	default:
+		log.Printf("Unknown WEB_MODE %q, fetching live", mode)
+		mode = ModeLive
+	}
+	return mode, dir
+}
+
+// SetMode changes the mode and fixtures of the clients which don't set their own, and of the LLM client,
+// e.g. so that tests replay fixtures whatever WEB_MODE says
+func SetMode(mode string, dir string) {
+	default_lock.Lock()
+	defer default_lock.Unlock()
+	default_mode, default_dir = mode, dir
+}
+
+func defaultMode() (string, string) {
+	default_lock.RLock()
+	defer default_lock.RUnlock()
+	return default_mode, default_dir
+}
+
+// Transport wraps a transport so that it records or replays responses according to WEB_MODE or SetMode,
+// for http clients which don't go through a Client, like the LLM one
+func Transport(live http.RoundTripper) http.RoundTripper {
+	return newTransport("", "", live)
+}
+
+func newTransport(mode string, dir string, live http.RoundTripper) http.RoundTripper {
+	if live == nil {
+		live = http.DefaultTransport
+	}
+	return &replayTransport{mode: mode, dir: dir, live: live}
+}
+
+// settings of a transport, which are the defaults unless it has its own
+func (t *replayTransport) settings() (string, string) {
+	mode, dir := defaultMode()
+	if t.mode != "" {
+		mode = t.mode
+	}
+	if t.dir != "" {
+		dir = t.dir
+	}
+	return mode, dir
+}
+
+type replayKeyContextKey struct{}
+
+// WithReplayKey sets what a request is recorded and replayed under, instead of its body.
+// Requests whose body depends on more than their input, like an LLM prompt that includes rows from the database, need one,
+// or replays would miss whenever the database differs from when they were recorded.
+func WithReplayKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, replayKeyContextKey{}, key)
+}
+
+// paths of a recorded request. Requests with a body, like LLM calls, are keyed by their body too, or by their replay key.
+func (t *replayTransport) paths(req *http.Request, dir string) (string, string, error) {
+	key := req.Method + " " + req.URL.String()
+	if replay_key, ok := req.Context().Value(replayKeyContextKey{}).(string); ok {
+		key += "\n" + replay_key
+	} else if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", "", err
+		}
+		data, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return "", "", err
+		}
+		key += "\n" + string(data)
+	}
+	sum := sha256.Sum256([]byte(key))
+	host := strings.ReplaceAll(req.URL.Host, ":", "_")
+	base := filepath.Join(dir, host, hex.EncodeToString(sum[:8]))
+	return base + ".json", base + ".body", nil
+}
+
+func load(req *http.Request, meta_path string, body_path string) (*http.Response, error) {
+	meta, err := os.ReadFile(meta_path)
+	if err != nil {
+		return nil, err
+	}
+	var r recording
+	if err := json.Unmarshal(meta, &r); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", meta_path, err)
+	}
+	body, err := os.Open(body_path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := body.Stat()
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          body,
+		ContentLength: info.Size(),
+		Request:       req,
+	}, nil
+}
+
+func saveMeta(r recording, meta_path string) error {
+	meta, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(meta_path, meta, 0644)
+}
+
+// recordingBody copies a response body into its fixture as the caller reads it, so that large files, like GDELT zips,
+// never sit in memory. The fixture is only put in place once the body has been read to the end;
+// if the caller closes it early, the rest is read anyway, so that replays get the whole body.
+type recordingBody struct {
+	reader    io.Reader // tees the decompressed body into tmp
+	closers   []io.Closer
+	tmp       *os.File
+	r         recording
+	meta_path string
+	body_path string
+	done      bool
+}
+
+func (b *recordingBody) finish(read_err error) {
+	if b.done {
+		return
+	}
+	b.done = true
+	close_err := b.tmp.Close()
+	if read_err == nil {
+		read_err = close_err
+	}
+	if read_err == nil {
+		read_err = os.Rename(b.tmp.Name(), b.body_path)
+	}
+	if read_err == nil {
+		read_err = saveMeta(b.r, b.meta_path)
+	}
+	if read_err != nil {
+		os.Remove(b.tmp.Name())
+		log.Printf("Error recording %s: %v", b.r.URL, read_err)
+	}
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.reader.Read(p)
+	if err == io.EOF {
+		b.finish(nil)
+	} else if err != nil {
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	if !b.done {
+		_, err := io.Copy(io.Discard, b.reader)
+		b.finish(err)
+	}
+	var err error
+	for _, closer := range b.closers {
+		if close_err := closer.Close(); err == nil {
+			err = close_err
+		}
+	}
+	return err
+}
+
+// readCloser closes several things at once, e.g. a gzip reader and the body under it
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (rc readCloser) Close() error {
+	var err error
+	for _, closer := range rc.closers {
+		if close_err := closer.Close(); err == nil {
+			err = close_err
+		}
+	}
+	return err
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	mode, dir := t.settings()
+	if mode == ModeLive {
+		return t.live.RoundTrip(req)
+	}
+	meta_path, body_path, err := t.paths(req, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == ModeReplay || mode == ModeCache {
+		resp, err := load(req, meta_path, body_path)
+		if err == nil {
+			return resp, nil
+		}
+		if mode == ModeReplay && errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w for %s %s", ErrNotRecorded, req.Method, req.URL)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	// Ask for the full response, so that what we record can be replayed on its own
+	live_req := req.Clone(req.Context())
+	live_req.Header.Del("If-None-Match")
+	live_req.Header.Del("If-Modified-Since")
+	resp, err := t.live.RoundTrip(live_req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The body is saved decompressed, so that fixtures can be read and edited
+	r := recording{Method: req.Method, URL: req.URL.String(), StatusCode: resp.StatusCode, Header: resp.Header.Clone(), Recorded: time.Now()}
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	r.Header.Del("Set-Cookie")
+
+	var reader io.Reader = resp.Body
+	closers := []io.Closer{resp.Body}
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("reading gzip body: %w", err)
+		}
+		reader = gz
+		closers = []io.Closer{gz, resp.Body}
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+
+	// Transient errors are retried, not replayed
+	if isRetryable(resp.StatusCode) {
+		resp.Body = readCloser{Reader: reader, closers: closers}
+		return resp, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(body_path), 0755); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(body_path), filepath.Base(body_path)+".*.tmp")
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	resp.Body = &recordingBody{
+		reader:    io.TeeReader(reader, tmp),
+		closers:   closers,
+		tmp:       tmp,
+		r:         r,
+		meta_path: meta_path,
+		body_path: body_path,
+	}
+	return resp, nil
+}
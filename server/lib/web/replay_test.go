@@ -0,0 +1,115 @@
+package web
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	large := strings.Repeat("0123456789", 100000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/large":
+			w.Write([]byte(large))
+		case "/gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write([]byte("compressed"))
+			gz.Close()
+		case "/llm":
+			body, _ := io.ReadAll(r.Body)
+			w.Write(body)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recorder := NewClient(Config{Mode: ModeRecord, FixturesDir: dir, RequestsPerSec: 1000, Burst: 1000})
+	replayer := NewClient(Config{Mode: ModeReplay, FixturesDir: dir, RequestsPerSec: 1000, Burst: 1000})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/large", large},
+		{"/gzip", "compressed"},
+	}
+	for _, test := range tests {
+		// Record first, then replay what was recorded
+		for _, client := range []struct {
+			name string
+			*Client
+		}{{"record", recorder}, {"replay", replayer}} {
+			name := client.name
+			got, err := client.Get(server.URL + test.path)
+			if err != nil {
+				t.Fatalf("%s %s: %v", name, test.path, err)
+			}
+			if string(got) != test.want {
+				t.Errorf("%s %s: got %d bytes, want %d", name, test.path, len(got), len(test.want))
+			}
+		}
+	}
+
+	// A body closed before the end is still recorded whole
+	resp, err := recorder.Open(server.URL + "/large?partial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Read(make([]byte, 10))
+	resp.Body.Close()
+	if got, err := replayer.Get(server.URL + "/large?partial"); err != nil || string(got) != large {
+		t.Errorf("partially read body: got %d bytes, %v", len(got), err)
+	}
+
+	if _, err := replayer.Get(server.URL + "/missing"); err == nil {
+		t.Errorf("replaying a request which wasn't recorded should fail")
+	}
+}
+
+func TestReplayKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	post := func(mode string, body string, key string) (string, error) {
+		client := &http.Client{Transport: newTransport(mode, dir, nil)}
+		ctx := context.Background()
+		if key != "" {
+			ctx = WithReplayKey(ctx, key)
+		}
+		req, err := http.NewRequestWithContext(ctx, "POST", server.URL, strings.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		return string(data), err
+	}
+
+	if _, err := post(ModeRecord, "prompt with stories A", "article"); err != nil {
+		t.Fatal(err)
+	}
+	// The same key replays, whatever the body
+	if got, err := post(ModeReplay, "prompt with stories B", "article"); err != nil || got != "prompt with stories A" {
+		t.Errorf("replay with the same key: got %q, %v", got, err)
+	}
+	// Without a key, the body is the key
+	if _, err := post(ModeReplay, "prompt with stories B", ""); err == nil {
+		t.Errorf("replaying an unrecorded body should fail")
+	}
+}
@@ -0,0 +1,154 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// robotsRules are the Allow and Disallow lines of a robots.txt that apply to us
+type robotsRules struct {
+	allow    []string
+	disallow []string
+	fetched  time.Time
+}
+
+const robotsMaxAge = 24 * time.Hour
+
+// parseRobots keeps the group for our user agent if there is one, and otherwise the group for "*"
+func parseRobots(data []byte, user_agent string) *robotsRules {
+	agent := strings.ToLower(user_agent)
+	groups := map[string]*robotsRules{}
+	var current []string // user agents of the group being read
+	in_rules := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if in_rules {
+				current, in_rules = nil, false
+			}
+			current = append(current, strings.ToLower(value))
+		case "allow", "disallow":
+			in_rules = true
+			for _, group_agent := range current {
+				rules, ok := groups[group_agent]
+				if !ok {
+					rules = &robotsRules{}
+					groups[group_agent] = rules
+				}
+				if value == "" {
+					continue // "Disallow:" with no path allows everything
+				}
+				if key == "allow" {
+					rules.allow = append(rules.allow, value)
+				} else {
+					rules.disallow = append(rules.disallow, value)
+				}
+			}
+		}
+	}
+
+	for group_agent, rules := range groups {
+		if group_agent != "*" && group_agent != "" && strings.Contains(agent, group_agent) {
+			return rules
+		}
+	}
+	if rules, ok := groups["*"]; ok {
+		return rules
+	}
+	return &robotsRules{}
+}
+
+// robotsPatternMatches handles the "*" and "$" wildcards
+func robotsPatternMatches(pattern string, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	return !anchored || len(parts) == 1 && rest == "" || len(parts) > 1 && strings.HasSuffix(path, parts[len(parts)-1])
+}
+
+// allowed applies the longest matching rule, with Allow winning ties
+func (r *robotsRules) allowed(path string) bool {
+	longest_allow, longest_disallow := -1, -1
+	for _, pattern := range r.allow {
+		if robotsPatternMatches(pattern, path) && len(pattern) > longest_allow {
+			longest_allow = len(pattern)
+		}
+	}
+	for _, pattern := range r.disallow {
+		if robotsPatternMatches(pattern, path) && len(pattern) > longest_disallow {
+			longest_disallow = len(pattern)
+		}
+	}
+	return longest_disallow < 0 || longest_allow >= longest_disallow
+}
+
+// allowedByRobots fetches a host's robots.txt at most once a day. A missing or unreachable robots.txt allows everything.
+func (c *Client) allowedByRobots(u *url.URL) bool {
+	host := u.Scheme + "://" + u.Host
+	c.lock.Lock()
+	rules, ok := c.robots[host]
+	c.lock.Unlock()
+
+	if !ok || time.Since(rules.fetched) > robotsMaxAge {
+		rules = &robotsRules{}
+		req, err := http.NewRequest("GET", host+"/robots.txt", nil)
+		if err == nil {
+			req.Header.Set("User-Agent", c.config.UserAgent)
+			if c.mode() != ModeReplay {
+				c.limiter(u.Host).wait()
+			}
+			resp, err := c.http.Do(req)
+			if err != nil {
+				log.Printf("Could not get robots.txt for %s, assuming everything is allowed: %v", host, err)
+			} else {
+				data, _ := io.ReadAll(io.LimitReader(resp.Body, 512<<10))
+				resp.Body.Close()
+				if resp.StatusCode == http.StatusOK {
+					rules = parseRobots(data, c.config.UserAgent)
+				}
+			}
+		}
+		rules.fetched = time.Now()
+		c.lock.Lock()
+		c.robots[host] = rules
+		c.lock.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	if !rules.allowed(path) {
+		log.Printf("robots.txt disallows %s", u)
+		return false
+	}
+	return true
+}
@@ -5,34 +5,13 @@ import (
 	"errors"
 	"golang.org/x/net/html"
 	"io"
-	"log"
-	"net/http"
 	"net/url"
 	"strings"
 )
 
-// wrap http.Get into a convenient handler
+// Get fetches a url with the shared DefaultClient
 func Get(url string) ([]byte, error) {
-
-	// log.Printf("Making request for url: %s", url)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		log.Printf("GET error: %v", err)
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Status error: %v", resp.StatusCode)
-		return nil, errors.New("Error: http status not OK")
-	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Error reading body: %v", err)
-		return nil, err
-	}
-	return body, nil
+	return DefaultClient.Get(url)
 }
 
 /* Html cleanup functions */
@@ -134,43 +113,6 @@ func GetTitle(body io.Reader) (string, error) {
 
 }
 
-func GetDiv(body io.Reader) (string, error) {
-	z := html.NewTokenizer(body)
-	title := ""
-	is_title := false
-	for {
-		tt := z.Next()
-
-		switch tt {
-		case html.ErrorToken:
-			if z.Err() == io.EOF {
-				return "", errors.New("No title found")
-			}
-			return "", z.Err()
-		case html.StartTagToken:
-			token := z.Token()
-			if "div" == token.Data {
-				for _, attr := range token.Attr {
-					if attr.Key == "class" && (attr.Val == "u-mainText" || attr.Val == "h-contentMain") {
-						is_title = true
-						break
-					}
-				}
-			}
-		case html.EndTagToken:
-			token := z.Token()
-			if "div" == token.Data && is_title == true {
-				return title, nil
-			}
-		default:
-			if is_title {
-				title += z.Token().String()
-			}
-		}
-	}
-
-}
-
 func findAndSkip(z *html.Tokenizer, tagName string) {
 	// Skip all tokens until the closing tag.
 	depth := 1
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"git.nunosempere.com/NunoSempere/news/lib/config"
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+)
+
+const feedsFile = "feeds.json"
+
+// FeedConfig is one entry in feeds.json
+type FeedConfig struct {
+	URL               string `json:"url"`
+	Origin            string `json:"origin"`                       // saved with each source, e.g. "UN News"
+	MaxAgeHours       int    `json:"max_age_hours,omitempty"`      // skip items older than this; 0 means the usual 15 days
+	ContentSelector   string `json:"content_selector,omitempty"`   // css selector for the article body; readability if empty or not found
+	ImportancePrompt  string `json:"importance_prompt,omitempty"`  // extra context about this feed for the importance check
+	ImportanceProfile string `json:"importance_profile,omitempty"` // see lib/llm/profiles.json; picked from each item's content if empty
+	Disabled          bool   `json:"disabled,omitempty"`
+}
+
+// LoadFeeds reads the feeds to follow from feeds.json
+func LoadFeeds() ([]FeedConfig, error) {
+	return config.LoadList(feedsFile, "feed", validateFeed, func(feed FeedConfig) bool { return feed.Disabled })
+}
+
+func validateFeed(feed *FeedConfig) ([]string, error) {
+	if !config.IsURL(feed.URL) {
+		return nil, fmt.Errorf("%q is not a url", feed.URL)
+	}
+	if feed.Origin == "" {
+		return nil, fmt.Errorf("%s has no origin", feed.URL)
+	}
+	if feed.MaxAgeHours < 0 {
+		return nil, fmt.Errorf("%s has a negative max_age_hours", feed.URL)
+	}
+	if _, ok := llm.GetProfile(feed.ImportanceProfile); feed.ImportanceProfile != "" && !ok {
+		return nil, fmt.Errorf("%s has an unknown importance_profile %q", feed.URL, feed.ImportanceProfile)
+	}
+	return []string{feed.URL}, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"git.nunosempere.com/NunoSempere/news/lib/feeds"
+	"git.nunosempere.com/NunoSempere/news/lib/filters"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// FilterAndExpandSource runs a feed item through the usual pipeline,
+// with the freshness window, content selector and importance prompt of its feed.
+func FilterAndExpandSource(item feeds.Item, feed FeedConfig, openrouter_key string, database_url string) (types.ExpandedSource, bool) {
+	es := types.ExpandedSource{
+		Title:  item.Title,
+		Link:   item.Link,
+		Date:   item.Date,
+		Origin: feed.Origin,
+	}
+
+	fs := []types.Filter{
+		filters.MaxAgeFilter(feed.MaxAgeHours),
+		filters.IsDupeFilter(database_url),
+		filters.IsGoodHostFilter(),
+		filters.CleanTitleFilter(),
+		filters.SelectorSummaryFilter(feed.ContentSelector, item.Summary, openrouter_key),
+		filters.WithRules(filters.ProfileImportanceFilterWithNote(feed.ImportanceProfile, importanceNote(feed), openrouter_key)),
+		filters.TranslateFilter(openrouter_key),
+		filters.ExtractEventFilter(openrouter_key),
+		filters.GeotagFilter(),
+		filters.TrackStoryFilter(openrouter_key, database_url),
+	}
+	return filters.ApplyFilters(es, fs)
+}
+
+// importanceNote tells the importance check what matters in the feed, if its config says
+func importanceNote(feed FeedConfig) string {
+	if feed.ImportancePrompt == "" {
+		return ""
+	}
+	return "Note about this source (" + feed.Origin + "): " + feed.ImportancePrompt
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feeds"
+	"git.nunosempere.com/NunoSempere/news/lib/pgx"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Set up logging
+	logFile, err := os.OpenFile("v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Load environment variables
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openrouter_key := os.Getenv("OPENROUTER_API_KEY")
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	feed_configs, err := LoadFeeds()
+	if err != nil {
+		log.Fatalf("Error loading feeds: %v", err)
+	}
+	log.Printf("Loaded %d feeds", len(feed_configs))
+
+	// Feeds send the same items for days, so remember which we have decided on, and when we first saw those without a date.
+	// Kept items are also caught by the duplicate filter after a restart.
+	processed := map[string]bool{}
+	first_seen := map[string]time.Time{}
+	for {
+		log.Println("Starting feeds processing")
+		for _, feed := range feed_configs {
+			// Feeds that haven't changed since last time come back empty (304 Not Modified)
+			items, err := feeds.Fetch(feed.URL)
+			if err != nil {
+				log.Printf("Error fetching %s feed: %v", feed.Origin, err)
+				continue
+			}
+			log.Printf("Found %d %s items", len(items), feed.Origin)
+
+			for i, item := range items {
+				if processed[item.Link] {
+					continue
+				}
+				// Undated items are as old as the first time we saw them, so that they age out if they keep failing
+				if !item.HasDate {
+					if _, ok := first_seen[item.Link]; !ok {
+						first_seen[item.Link] = item.Date
+					}
+					item.Date = first_seen[item.Link]
+				}
+				log.Printf("\nProcessing %s item %d/%d: %s (%v)", feed.Origin, i+1, len(items), item.Title, item.Date)
+
+				es, ok := FilterAndExpandSource(item, feed, openrouter_key, pg_database_url)
+				if !ok && es.Failed {
+					log.Printf("Will try again on the next poll")
+					continue
+				}
+				processed[item.Link] = true
+				delete(first_seen, item.Link)
+				if ok {
+					pgx.SaveSource(es)
+				}
+			}
+		}
+		log.Printf("Finished processing feeds, sleeping for half an hour")
+		time.Sleep(30 * time.Minute)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const feedsFile = "feeds.txt"
+
+type AlertFeed struct {
+	Keyword string
+	URL     string
+}
+
+// LoadFeeds reads feeds.txt, and errors on anything that doesn't look like a Google Alerts feed,
+// so that a typo is caught at startup rather than failing every half hour.
+func LoadFeeds() ([]AlertFeed, error) {
+	f, err := os.Open(feedsFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var feeds []AlertFeed
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	line_num := 0
+	for scanner.Scan() {
+		line_num++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		keyword, feed_url, found := strings.Cut(line, ": ")
+		keyword, feed_url = strings.TrimSpace(keyword), strings.TrimSpace(feed_url)
+		if !found || keyword == "" || feed_url == "" {
+			return nil, fmt.Errorf("%s:%d: expected \"keyword: feed url\", got %q", feedsFile, line_num, line)
+		}
+		parsed, err := url.Parse(feed_url)
+		if err != nil || parsed.Scheme != "https" || parsed.Host != "www.google.com" || !strings.HasPrefix(parsed.Path, "/alerts/feeds/") {
+			return nil, fmt.Errorf("%s:%d: %q is not a Google Alerts feed url", feedsFile, line_num, feed_url)
+		}
+		if seen[keyword] {
+			return nil, fmt.Errorf("%s:%d: keyword %q appears twice", feedsFile, line_num, keyword)
+		}
+		seen[keyword] = true
+		feeds = append(feeds, AlertFeed{Keyword: keyword, URL: feed_url})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(feeds) == 0 {
+		return nil, fmt.Errorf("%s has no feeds", feedsFile)
+	}
+	return feeds, nil
+}
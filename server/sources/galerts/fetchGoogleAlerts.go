@@ -27,36 +27,6 @@ type link struct {
 	Url string `xml:"href,attr"`
 }
 
-func KeywordToRSSFeed(keyword string) (string, error) {
-
-	feedMap := map[string]string{
-		"War":                  "https://www.google.com/alerts/feeds/12823167512648692611/14775069330880237129",
-		"Emergency":            "https://www.google.com/alerts/feeds/12823167512648692611/10752650238419774131",
-		"disaster":             "https://www.google.com/alerts/feeds/12823167512648692611/1731039193900529474",
-		"alert":                "https://www.google.com/alerts/feeds/12823167512648692611/1731039193900529512",
-		"nuclear":              "https://www.google.com/alerts/feeds/12823167512648692611/16302398974188618783",
-		"human-to-human":       "https://www.google.com/alerts/feeds/12823167512648692611/16363046595406729950",
-		"pandemic":             "https://www.google.com/alerts/feeds/12823167512648692611/1689941676777225519",
-		"blockade":             "https://www.google.com/alerts/feeds/12823167512648692611/8592384102868889996",
-		"invasion":             "https://www.google.com/alerts/feeds/12823167512648692611/14400132195257773260",
-		"undersea cables":      "https://www.google.com/alerts/feeds/12823167512648692611/16660286502183277886",
-		"Carrington event":     "https://www.google.com/alerts/feeds/12823167512648692611/17032681478781817561",
-		"mystery pneumonia":    "https://www.google.com/alerts/feeds/12823167512648692611/17032681478781820157",
-		"China Taiwan":         "https://www.google.com/alerts/feeds/12823167512648692611/3055804732710246461",
-		"Russia Ukraine":       "https://www.google.com/alerts/feeds/12823167512648692611/16094280695893389744",
-		"OpenAI announces AGI": "https://www.google.com/alerts/feeds/12823167512648692611/500375972710348852",
-		"military exercise":    "https://www.google.com/alerts/feeds/12823167512648692611/267614087142809738",
-		"Kessler syndrome":     "https://www.google.com/alerts/feeds/12823167512648692611/14873084661553437561",
-		"Cyberattack":          "https://www.google.com/alerts/feeds/12823167512648692611/4267352864131660551",
-	}
-
-	if identifier, exists := feedMap[keyword]; exists && identifier != "" {
-		return identifier, nil
-	}
-
-	return "", fmt.Errorf("identifier not found in alerts map")
-}
-
 func extractActualLink(encodedURL string) (string, error) {
 
 	/*
@@ -81,15 +51,10 @@ func extractActualLink(encodedURL string) (string, error) {
 	return targetURL, nil
 }
 
-func SearchGoogleAlerts(query string) ([]types.Source, error) {
-	log.Printf("Making google alerts request for query: %s", query)
-
-	url, err := KeywordToRSSFeed(query)
-	if err != nil {
-		return nil, err
-	}
+func SearchGoogleAlerts(alert_feed AlertFeed) ([]types.Source, error) {
+	log.Printf("Making google alerts request for query: %s", alert_feed.Keyword)
 
-	xml_bytes, err := web.Get(url)
+	xml_bytes, err := web.Get(alert_feed.URL)
 	if err != nil {
 		return nil, err
 	}
@@ -118,16 +83,15 @@ func SearchGoogleAlerts(query string) ([]types.Source, error) {
 	return sources, nil
 }
 
-func TestGoogleAlerts() {
-	keywords := []string{"War", "Emergency", "disaster"}
-	for _, keyword := range keywords {
-		log.Printf("Testing Google Alerts for keyword: %s", keyword)
-		sources, err := SearchGoogleAlerts(keyword)
+func TestGoogleAlerts(alert_feeds []AlertFeed) {
+	for _, alert_feed := range alert_feeds[:min(3, len(alert_feeds))] {
+		log.Printf("Testing Google Alerts for keyword: %s", alert_feed.Keyword)
+		sources, err := SearchGoogleAlerts(alert_feed)
 		if err != nil {
-			log.Printf("Error searching Google Alerts for %s: %v", keyword, err)
+			log.Printf("Error searching Google Alerts for %s: %v", alert_feed.Keyword, err)
 			continue
 		}
-		log.Printf("Found %d sources for keyword %s:", len(sources), keyword)
+		log.Printf("Found %d sources for keyword %s:", len(sources), alert_feed.Keyword)
 		for _, source := range sources {
 			log.Printf("Title: %s", source.Title)
 			log.Printf("Link: %s", source.Link)
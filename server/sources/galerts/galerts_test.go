@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"git.nunosempere.com/NunoSempere/news/lib/golden"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+// fixtures/web is synthetic for now; see fixtures/README.md
+func TestMain(m *testing.M) {
+	web.SetMode(web.ModeReplay, "fixtures/web")
+	os.Exit(m.Run())
+}
+
+func TestSearchGoogleAlerts(t *testing.T) {
+	tests := []struct {
+		name  string
+		alert AlertFeed
+	}{
+		{"war", AlertFeed{Keyword: "War", URL: "https://www.google.com/alerts/feeds/12823167512648692611/14775069330880237129"}},
+		{"emergency", AlertFeed{Keyword: "Emergency", URL: "https://www.google.com/alerts/feeds/12823167512648692611/10752650238419774131"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sources, err := SearchGoogleAlerts(test.alert)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.Check(t, test.name, sources)
+		})
+	}
+}
@@ -31,37 +31,60 @@ func main() {
 	openrouter_key := os.Getenv("OPENROUTER_API_KEY")
 	pg_database_url := os.Getenv("DATABASE_POOL_URL")
 
-	keywords := []string{"War", "Emergency", "disaster", "alert", "nuclear", "combat duty", "human-to-human", "pandemic", "blockade", "invasion", "undersea cables", "nuclear", "Carrington event", "mystery pneumonia", "Taiwan", "Ukraine", "OpenAI announces AGI", "AI rights", "military exercise", "Kessler syndrome", "Cyberattack"}
+	alert_feeds, err := LoadFeeds()
+	if err != nil {
+		log.Fatalf("Error loading Google Alerts feeds: %v", err)
+	}
+	log.Printf("Loaded %d Google Alerts feeds", len(alert_feeds))
+
+	// Cheap filters first, so that we can count how many items make it to the llm
+	cheap_filters := []types.Filter{
+		filters.IsFreshFilter(),
+		filters.IsDupeFilter(pg_database_url),
+		filters.IsGoodHostFilter(),
+		filters.CleanTitleFilter(),
+	}
+	expensive_filters := []types.Filter{
+		filters.ExtractSummaryFilter(openrouter_key),
+		filters.WithRules(filters.CheckImportanceFilter(openrouter_key)),
+		filters.ExtractEventFilter(openrouter_key),
+		filters.GeotagFilter(),
+		filters.TrackStoryFilter(openrouter_key, pg_database_url),
+	}
+
 	for {
 		log.Println("(Re)starting Google Alerts keyword loop")
-		for _, keyword := range keywords {
-			log.Printf("Keyword: %v", keyword)
-			articles, err := SearchGoogleAlerts(keyword)
+		for _, alert_feed := range alert_feeds {
+			log.Printf("Keyword: %v", alert_feed.Keyword)
+			articles, err := SearchGoogleAlerts(alert_feed)
 			if err != nil {
 				log.Printf("Google Alerts error: %v", err)
+				saveStats(pg_database_url, alert_feed, KeywordStats{}, err)
 				continue
 			}
 
 			log.Printf("Number of articles in keyword: %v", len(articles))
+			stats := KeywordStats{Fetched: len(articles)}
 
 			for i, article := range articles {
-				log.Printf("\nArticle #%v/%v [keyword \"%v\"]: %v (%v)", i, len(articles), keyword, article.Title, article.Date)
+				log.Printf("\nArticle #%v/%v [keyword \"%v\"]: %v (%v)", i, len(articles), alert_feed.Keyword, article.Title, article.Date)
 
 				es := types.ExpandedSource{Title: article.Title, Link: article.Link, Date: article.Date}
 
-				fs := []types.Filter{
-					filters.IsFreshFilter(),
-					filters.IsDupeFilter(pg_database_url),
-					filters.IsGoodHostFilter(),
-					filters.CleanTitleFilter(),
-					filters.ExtractSummaryFilter(openrouter_key),
-					filters.CheckImportanceFilter(openrouter_key),
+				es, ok := filters.ApplyFilters(es, cheap_filters)
+				if !ok {
+					continue
 				}
-				es, ok := filters.ApplyFilters(es, fs)
+				stats.Passed++
+
+				es, ok = filters.ApplyFilters(es, expensive_filters)
 				if ok {
 					pgx.SaveSource(es)
+					stats.Kept++
 				}
 			}
+			log.Printf("Keyword %q: %d fetched, %d passed, %d kept", alert_feed.Keyword, stats.Fetched, stats.Passed, stats.Kept)
+			saveStats(pg_database_url, alert_feed, stats, nil)
 		}
 		log.Printf("Finished Google Alerts batch, pausing for half an hour")
 		time.Sleep(1800 * time.Second) // stagger a little bit
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// KeywordStats counts what happened to one keyword's items in one pass
+type KeywordStats struct {
+	Fetched int // items in the feed
+	Passed  int // items which passed the cheap filters (fresh, not a dupe, good host) and were sent to the llm
+	Kept    int // items which passed every filter and were saved
+}
+
+// saveStats adds a pass's stats to the keyword's running totals in galerts_stats
+func saveStats(database_url string, feed AlertFeed, stats KeywordStats, fetch_err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return
+	}
+	defer conn.Close(context.Background())
+
+	n_errors := 0
+	var last_success *time.Time
+	if fetch_err != nil {
+		n_errors = 1
+	} else {
+		now := time.Now()
+		last_success = &now
+	}
+
+	_, err = conn.Exec(ctx, `
+		INSERT INTO galerts_stats (keyword, feed_url, fetched, passed, kept, errors, last_fetch, last_success)
+		VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, $7)
+		ON CONFLICT (keyword) DO UPDATE SET
+			feed_url = EXCLUDED.feed_url,
+			fetched = galerts_stats.fetched + EXCLUDED.fetched,
+			passed = galerts_stats.passed + EXCLUDED.passed,
+			kept = galerts_stats.kept + EXCLUDED.kept,
+			errors = galerts_stats.errors + EXCLUDED.errors,
+			last_fetch = EXCLUDED.last_fetch,
+			last_success = COALESCE(EXCLUDED.last_success, galerts_stats.last_success)
+	`, feed.Keyword, feed.URL, stats.Fetched, stats.Passed, stats.Kept, n_errors, last_success)
+	if err != nil {
+		log.Printf("Error saving galerts stats: %v\n", err)
+	}
+}
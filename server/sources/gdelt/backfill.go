@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+var backfillTimeFormats = []string{"2006-01-02T15:04", "2006-01-02"}
+
+func parseBackfillTime(s string) (time.Time, error) {
+	var err error
+	for _, format := range backfillTimeFormats {
+		var t time.Time
+		t, err = time.Parse(format, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// runBackfill reprocesses the windows in a historical range, e.g. to try a new prompt:
+//
+//	make backfill FROM=2025-01-01 TO=2025-01-02T12:00
+//
+// Times are UTC, like GDELT's. Articles already in the database are skipped as usual, unless -reprocess is given:
+// then they are judged again, and saved over what we had. The progress the daemon keeps is left untouched.
+func runBackfill(args []string, feeds []*Feed, openrouter_key string, pg_database_url string) {
+	flags := flag.NewFlagSet("backfill", flag.ExitOnError)
+	from_str := flags.String("from", "", "start of the range, e.g. 2025-01-01 or 2025-01-01T15:00 (UTC)")
+	to_str := flags.String("to", "", "end of the range, exclusive")
+	feeds_str := flags.String("feeds", "gkg,events", "which feeds to reprocess")
+	reprocess := flags.Bool("reprocess", false, "judge articles already in the database again, and replace them")
+	flags.Parse(args)
+
+	from, err := parseBackfillTime(*from_str)
+	if err != nil {
+		log.Fatalf("Bad -from %q: %v", *from_str, err)
+	}
+	to, err := parseBackfillTime(*to_str)
+	if err != nil {
+		log.Fatalf("Bad -to %q: %v", *to_str, err)
+	}
+	if !from.Before(to) {
+		log.Fatalf("-from (%v) should be before -to (%v)", from, to)
+	}
+
+	windows, err := getWindows(from, to)
+	if err != nil {
+		log.Fatalf("Couldn't list windows: %v", err)
+	}
+	log.Printf("Backfilling %d windows from %v to %v", len(windows), from, to)
+
+	mode := modeBackfill
+	if *reprocess {
+		mode = modeReprocess
+	}
+	kinds := strings.Split(*feeds_str, ",")
+	for _, window := range windows {
+		for _, feed := range feeds {
+			if !slices.Contains(kinds, feed.Kind) {
+				continue
+			}
+			if err := processWindow(feed, window, openrouter_key, pg_database_url, mode); err != nil {
+				log.Printf("%s: giving up on window %v", feed.Name, window.Timestamp)
+			}
+		}
+	}
+	log.Printf("Finished backfill")
+}
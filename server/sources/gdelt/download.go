@@ -0,0 +1,278 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+// GDELT publishes three files every 15 minutes. lastupdate.txt lists the latest ones,
+// and masterfilelist.txt all of them since 2015, one per line: size, md5 and url.
+const (
+	lastUpdateURL     = "http://data.gdeltproject.org/gdeltv2/lastupdate.txt"
+	masterFileListURL = "http://data.gdeltproject.org/gdeltv2/masterfilelist.txt"
+	windowLength      = 15 * time.Minute
+
+	kindExport   = "export" // events
+	kindMentions = "mentions"
+	kindGKG      = "gkg"
+)
+
+// File names follow the window, e.g. http://data.gdeltproject.org/gdeltv2/20150218230000.export.CSV.zip
+const gdeltFilePrefix = "http://data.gdeltproject.org/gdeltv2/"
+
+var file_suffixes = map[string]string{
+	kindExport:   ".export.CSV.zip",
+	kindMentions: ".mentions.CSV.zip",
+	kindGKG:      ".gkg.csv.zip",
+}
+
+// GDELT sometimes skips a file, which is then not in the file lists; there is nothing to retry then
+var errMissingFile = errors.New("window has no such file")
+
+// A listed file which isn't there yet. These are retried, until maxPublishDelay has passed.
+var errNotPublished = errors.New("file not published yet")
+
+// How long after its window a listed file can still appear; after this, a 404 means GDELT lost it
+const maxPublishDelay = 6 * time.Hour
+
+type GDELTFile struct {
+	Timestamp time.Time
+	Kind      string
+	Link      string
+	Size      int64
+	MD5       string
+}
+
+// Window is one 15 minute interval, with its files by kind
+type Window struct {
+	Timestamp time.Time
+	Files     map[string]GDELTFile
+}
+
+func (w Window) File(kind string) (GDELTFile, error) {
+	file, ok := w.Files[kind]
+	if !ok {
+		return GDELTFile{}, fmt.Errorf("%w: %s at %s", errMissingFile, kind, w.Timestamp.Format(gdeltDateTimeFormat))
+	}
+	return file, nil
+}
+
+// parseFileListLine parses e.g. "150383 297a16b493de7cf6ca809a7cc31d0b93 http://data.gdeltproject.org/gdeltv2/20150218230000.export.CSV.zip"
+func parseFileListLine(line string) (GDELTFile, error) {
+	parts := strings.Fields(line)
+	if len(parts) != 3 {
+		return GDELTFile{}, fmt.Errorf("expected 3 fields, got %d", len(parts))
+	}
+	size, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return GDELTFile{}, fmt.Errorf("bad size %q: %w", parts[0], err)
+	}
+	name := path.Base(parts[2])
+	timestamp_str, rest, found := strings.Cut(name, ".")
+	if !found {
+		return GDELTFile{}, fmt.Errorf("bad file name %q", name)
+	}
+	timestamp, err := time.Parse(gdeltDateTimeFormat, timestamp_str)
+	if err != nil {
+		return GDELTFile{}, fmt.Errorf("bad timestamp in %q: %w", name, err)
+	}
+	kind, _, _ := strings.Cut(strings.ToLower(rest), ".")
+	return GDELTFile{Timestamp: timestamp, Kind: kind, Link: parts[2], Size: size, MD5: parts[1]}, nil
+}
+
+func getLatestWindow() (Window, error) {
+	resp, err := gdeltClient.Open(lastUpdateURL)
+	if err != nil {
+		return Window{}, fmt.Errorf("fetching lastupdate.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	window := Window{Files: map[string]GDELTFile{}}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		file, err := parseFileListLine(scanner.Text())
+		if err != nil {
+			return Window{}, fmt.Errorf("parsing lastupdate.txt: %w", err)
+		}
+		window.Timestamp = file.Timestamp
+		window.Files[file.Kind] = file
+	}
+	if err := scanner.Err(); err != nil {
+		return Window{}, fmt.Errorf("reading response: %w", err)
+	} else if len(window.Files) == 0 {
+		return Window{}, fmt.Errorf("lastupdate.txt is empty")
+	}
+	return window, nil
+}
+
+// getWindows walks masterfilelist.txt for the windows in [from, to), with the size and md5 of each file.
+// The list is large and sorted by time, so it is read as a stream and we stop at the end of the range.
+// Windows GDELT skipped are not in it.
+func getWindows(from time.Time, to time.Time) ([]Window, error) {
+	resp, err := gdeltClient.Open(masterFileListURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching masterfilelist.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var windows []Window
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		file, err := parseFileListLine(scanner.Text())
+		if err != nil {
+			// Not an error; the list has a few malformed lines from 2015
+			continue
+		}
+		if file.Timestamp.Before(from) {
+			continue
+		}
+		if !file.Timestamp.Before(to) {
+			break
+		}
+		if len(windows) == 0 || !windows[len(windows)-1].Timestamp.Equal(file.Timestamp) {
+			windows = append(windows, Window{Timestamp: file.Timestamp, Files: map[string]GDELTFile{}})
+		}
+		windows[len(windows)-1].Files[file.Kind] = file
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading masterfilelist.txt: %w", err)
+	}
+	return windows, nil
+}
+
+// Downloaded files are kept here, so that restarts and backfills don't download them again
+func cacheDir() string {
+	if dir := os.Getenv("GDELT_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	return "cache"
+}
+
+const maxCacheAge = 3 * 24 * time.Hour
+
+// masterfilelist.txt and the zips are larger than the shared client allows, and slow to download
+var gdeltClient = web.NewClient(web.Config{Timeout: 10 * time.Minute, MaxBodySize: -1})
+
+// downloadFile saves a GDELT file into the cache, checking its size and md5 against the file list.
+// It writes to a temporary file first, so that an interrupted download never ends up in the cache.
+func downloadFile(file GDELTFile, cached_path string) error {
+	log.Printf("Downloading %v", file.Link)
+	resp, err := gdeltClient.Open(file.Link)
+	var fetch_err *web.FetchError
+	if errors.As(err, &fetch_err) && fetch_err.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", errNotPublished, file.Link)
+	} else if err != nil {
+		return fmt.Errorf("downloading file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(cached_path), filepath.Base(cached_path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	hash := md5.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), resp.Body)
+	if close_err := tmp.Close(); err == nil {
+		err = close_err
+	}
+	if err != nil {
+		return fmt.Errorf("saving download: %w", err)
+	}
+
+	if file.Size > 0 && size != file.Size {
+		return fmt.Errorf("downloaded %d bytes, expected %d", size, file.Size)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); file.MD5 != "" && !strings.EqualFold(sum, file.MD5) {
+		return fmt.Errorf("md5 of download is %s, expected %s", sum, file.MD5)
+	}
+	return os.Rename(tmp.Name(), cached_path)
+}
+
+// getCachedFile returns the path of a GDELT file in the cache, downloading it if needed
+func getCachedFile(file GDELTFile) (string, error) {
+	if err := os.MkdirAll(cacheDir(), 0755); err != nil {
+		return "", fmt.Errorf("creating cache dir: %w", err)
+	}
+	cached_path := filepath.Join(cacheDir(), path.Base(file.Link))
+
+	// Files only get into the cache after being verified, so checking the size is enough here
+	if info, err := os.Stat(cached_path); err == nil && (file.Size == 0 || info.Size() == file.Size) {
+		log.Printf("Using cached %v", cached_path)
+		return cached_path, nil
+	}
+	if err := downloadFile(file, cached_path); err != nil {
+		return "", err
+	}
+	return cached_path, nil
+}
+
+// pruneCache removes cached files we are unlikely to need again
+func pruneCache() {
+	entries, err := os.ReadDir(cacheDir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < maxCacheAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(cacheDir(), entry.Name())); err != nil {
+			log.Printf("Error pruning GDELT cache: %v", err)
+		}
+	}
+}
+
+// zippedFile closes both the csv and the archive it is in
+type zippedFile struct {
+	io.ReadCloser
+	archive *zip.ReadCloser
+}
+
+func (z zippedFile) Close() error {
+	z.ReadCloser.Close()
+	return z.archive.Close()
+}
+
+// openZippedFile gets a GDELT zip file and opens the one csv inside it, to be read as a stream
+func openZippedFile(file GDELTFile) (io.ReadCloser, error) {
+	cached_path, err := getCachedFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.OpenReader(cached_path)
+	if err != nil {
+		// Don't keep a file we can't read
+		os.Remove(cached_path)
+		return nil, fmt.Errorf("reading zip file %s: %w", path.Base(cached_path), err)
+	}
+	if len(archive.File) == 0 {
+		archive.Close()
+		return nil, fmt.Errorf("zip file %s is empty", path.Base(cached_path))
+	}
+
+	csv, err := archive.File[0].Open()
+	if err != nil {
+		archive.Close()
+		return nil, fmt.Errorf("opening zipped file: %w", err)
+	}
+	return zippedFile{ReadCloser: csv, archive: archive}, nil
+}
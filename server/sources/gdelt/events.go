@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/geo"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// The Events 2.0 export has 61 tab separated columns, and the Mentions table 16;
+// see guides/gdelt/GDELT-Event_Codebook-V2.0.txt
+const (
+	eventGlobalID       = 0
+	eventActor1Name     = 6
+	eventActor2Name     = 16
+	eventIsRoot         = 25
+	eventCode           = 26
+	eventRootCode       = 28
+	eventQuadClass      = 29
+	eventGoldstein      = 30
+	eventNumMentions    = 31
+	eventNumSources     = 32
+	eventAvgTone        = 34
+	eventActionGeoName  = 52
+	eventActionGeoFIPS  = 53
+	eventActionGeoLat   = 56
+	eventActionGeoLon   = 57
+	eventDateAdded      = 59
+	eventSourceURL      = 60
+	eventNumColumns     = 61
+	mentionGlobalID     = 0
+	mentionIdentifier   = 5
+	mentionConfidence   = 11
+	mentionNumColumns   = 16
+	gdeltDateTimeFormat = "20060102150405"
+)
+
+// CAMEO root codes, from guides/gdelt/GDELT-Event_Codebook-V2.0.txt
+var cameoRootNames = map[string]string{
+	"01": "Make public statement",
+	"02": "Appeal",
+	"03": "Express intent to cooperate",
+	"04": "Consult",
+	"05": "Engage in diplomatic cooperation",
+	"06": "Engage in material cooperation",
+	"07": "Provide aid",
+	"08": "Yield",
+	"09": "Investigate",
+	"10": "Demand",
+	"11": "Disapprove",
+	"12": "Reject",
+	"13": "Threaten",
+	"14": "Protest",
+	"15": "Exhibit force posture",
+	"16": "Reduce relations",
+	"17": "Coerce",
+	"18": "Assault",
+	"19": "Fight",
+	"20": "Use unconventional mass violence",
+}
+
+type EventRecord struct {
+	GlobalID       string
+	Actor1         string
+	Actor2         string
+	IsRootEvent    bool
+	EventCode      string
+	RootCode       string
+	QuadClass      int
+	Goldstein      float64
+	NumMentions    int // over the event's whole life, not just this window
+	NumSources     int
+	AvgTone        float64
+	ActionGeo      *types.Location
+	DateAdded      time.Time
+	SourceURL      string
+	WindowMentions int // mentions in this 15 minute window, from the Mentions table
+}
+
+func ParseEventRecord(line string) (EventRecord, error) {
+	columns := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+	if len(columns) < eventNumColumns {
+		return EventRecord{}, fmt.Errorf("expected %d columns, got %d", eventNumColumns, len(columns))
+	}
+	date_added, err := time.Parse(gdeltDateTimeFormat, columns[eventDateAdded])
+	if err != nil {
+		return EventRecord{}, fmt.Errorf("bad date %q: %w", columns[eventDateAdded], err)
+	}
+	goldstein, err := strconv.ParseFloat(columns[eventGoldstein], 64)
+	if err != nil {
+		return EventRecord{}, fmt.Errorf("bad goldstein score %q: %w", columns[eventGoldstein], err)
+	}
+	if !strings.HasPrefix(columns[eventSourceURL], "http") {
+		return EventRecord{}, fmt.Errorf("source is not a url: %q", columns[eventSourceURL])
+	}
+
+	record := EventRecord{
+		GlobalID:    columns[eventGlobalID],
+		Actor1:      columns[eventActor1Name],
+		Actor2:      columns[eventActor2Name],
+		IsRootEvent: columns[eventIsRoot] == "1",
+		EventCode:   columns[eventCode],
+		RootCode:    columns[eventRootCode],
+		Goldstein:   goldstein,
+		DateAdded:   date_added,
+		SourceURL:   columns[eventSourceURL],
+	}
+	// Optional fields; left empty if missing
+	record.QuadClass, _ = strconv.Atoi(columns[eventQuadClass])
+	record.NumMentions, _ = strconv.Atoi(columns[eventNumMentions])
+	record.NumSources, _ = strconv.Atoi(columns[eventNumSources])
+	record.AvgTone, _ = strconv.ParseFloat(columns[eventAvgTone], 64)
+
+	lat, err_lat := strconv.ParseFloat(columns[eventActionGeoLat], 64)
+	lon, err_lon := strconv.ParseFloat(columns[eventActionGeoLon], 64)
+	if columns[eventActionGeoName] != "" && err_lat == nil && err_lon == nil {
+		country_code, _ := geo.ISOFromFIPS(columns[eventActionGeoFIPS])
+		record.ActionGeo = &types.Location{Name: columns[eventActionGeoName], CountryCode: country_code, Lat: lat, Lon: lon}
+	}
+	return record, nil
+}
+
+type MentionRecord struct {
+	GlobalID   string
+	Identifier string // usually the url of the mentioning article
+	Confidence int
+}
+
+func ParseMentionRecord(line string) (MentionRecord, error) {
+	columns := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+	if len(columns) < mentionNumColumns {
+		return MentionRecord{}, fmt.Errorf("expected %d columns, got %d", mentionNumColumns, len(columns))
+	}
+	confidence, _ := strconv.Atoi(columns[mentionConfidence])
+	return MentionRecord{GlobalID: columns[mentionGlobalID], Identifier: columns[mentionIdentifier], Confidence: confidence}, nil
+}
+
+// forEachLine calls f on each line of a GDELT csv, skipping and counting the lines f can't handle
+func forEachLine(r io.Reader, name string, f func(line string) error) error {
+	reader := bufio.NewReader(r)
+	n_lines, n_bad_lines := 0, 0
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			n_lines++
+			if line_err := f(line); line_err != nil {
+				n_bad_lines++
+				if n_bad_lines <= 5 {
+					log.Printf("Skipping bad %s line #%d: %v", name, n_lines, line_err)
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			log.Printf("Error reading %s content: %v", name, err)
+			return err
+		}
+	}
+	log.Printf("Read %d %s lines, skipped %d bad ones", n_lines, name, n_bad_lines)
+	return nil
+}
+
+// countMentions counts how often each of the given events was mentioned in this window
+func countMentions(r io.Reader, events map[string]*EventRecord) error {
+	return forEachLine(r, "mentions", func(line string) error {
+		mention, err := ParseMentionRecord(line)
+		if err != nil {
+			return err
+		}
+		if event, ok := events[mention.GlobalID]; ok {
+			event.WindowMentions++
+		}
+		return nil
+	})
+}
+
+func eventTitle(event EventRecord) string {
+	action, ok := cameoRootNames[event.RootCode]
+	if !ok {
+		action = "CAMEO event " + event.EventCode
+	}
+	title := action
+	if event.Actor1 != "" {
+		title = event.Actor1 + ": " + strings.ToLower(action)
+	}
+	if event.Actor2 != "" {
+		title += " (" + event.Actor2 + ")"
+	}
+	if event.ActionGeo != nil {
+		title += " in " + event.ActionGeo.Name
+	}
+	return title
+}
+
+func eventToSource(event EventRecord) types.Source {
+	structured_event := &types.StructuredEvent{EventType: strings.ToLower(cameoRootNames[event.RootCode])}
+	for _, actor := range []string{event.Actor1, event.Actor2} {
+		if actor != "" {
+			structured_event.Actors = append(structured_event.Actors, actor)
+		}
+	}
+	var locations []types.Location
+	if event.ActionGeo != nil {
+		locations = []types.Location{*event.ActionGeo}
+		structured_event.Countries = geo.CountryCodes(locations)
+	}
+	return types.Source{
+		Title:     eventTitle(event),
+		Link:      event.SourceURL,
+		Date:      event.DateAdded,
+		Event:     structured_event,
+		Locations: locations,
+	}
+}
+
+// SearchEvents reads a window's Events export, selects events by CAMEO root code and Goldstein score,
+// and ranks them by how often they were mentioned in that window.
+func SearchEvents(window Window, selection Selection) ([]types.Source, error) {
+	export, err := window.File(kindExport)
+	if err != nil {
+		return nil, err
+	}
+
+	export_file, err := openZippedFile(export)
+	if err != nil {
+		return nil, err
+	}
+	defer export_file.Close()
+
+	// Keep one event per article, the most severe one
+	selected := map[string]*EventRecord{}
+	by_url := map[string]*EventRecord{}
+	err = forEachLine(export_file, "events", func(line string) error {
+		event, err := ParseEventRecord(line)
+		if err != nil {
+			return err
+		}
+		if _, ok := selection.SelectEvent(event); !ok {
+			return nil
+		}
+		if previous, ok := by_url[event.SourceURL]; ok {
+			if previous.Goldstein <= event.Goldstein {
+				return nil
+			}
+			delete(selected, previous.GlobalID)
+		}
+		selected[event.GlobalID] = &event
+		by_url[event.SourceURL] = &event
+		return nil
+	})
+	if err != nil {
+		// Partway through the file; fail the window so that it is tried again rather than half processed
+		return nil, err
+	}
+	log.Printf("Selected %d events", len(selected))
+
+	mentions, err := window.File(kindMentions)
+	var mentions_file io.ReadCloser
+	if err == nil {
+		mentions_file, err = openZippedFile(mentions)
+	}
+	if err != nil {
+		// Not fatal; we can still rank by the events' own mention counts
+		log.Printf("Could not get mentions, ranking by NumMentions: %v", err)
+	} else {
+		defer mentions_file.Close()
+		countMentions(mentions_file, selected)
+	}
+
+	var events []EventRecord
+	for _, event := range selected {
+		if event.WindowMentions == 0 {
+			event.WindowMentions = event.NumMentions
+		}
+		events = append(events, *event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].WindowMentions > events[j].WindowMentions
+	})
+	if selection.MaxEvents > 0 && len(events) > selection.MaxEvents {
+		events = events[:selection.MaxEvents]
+	}
+
+	var sources []types.Source
+	for _, event := range events {
+		log.Printf("Event %s (root code %s, goldstein %.1f, %d mentions): %s", event.GlobalID, event.RootCode, event.Goldstein, event.WindowMentions, event.SourceURL)
+		sources = append(sources, eventToSource(event))
+	}
+	return sources, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/golden"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+// Windows in fixtures/web, which is synthetic for now (see fixtures/README.md): the first has all three files.
+// GDELT lists the gkg file of the second, but it isn't there, and doesn't list its events.
+var (
+	recordedWindow = time.Date(2025, 5, 13, 17, 45, 0, 0, time.UTC)
+	skippedWindow  = recordedWindow.Add(windowLength)
+)
+
+func TestMain(m *testing.M) {
+	web.SetMode(web.ModeReplay, "fixtures/web")
+	cache_dir, err := os.MkdirTemp("", "gdelt-cache")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("GDELT_CACHE_DIR", cache_dir)
+	code := m.Run()
+	os.RemoveAll(cache_dir)
+	os.Exit(code)
+}
+
+func window(t *testing.T, timestamp time.Time) Window {
+	t.Helper()
+	windows, err := getWindows(timestamp, timestamp.Add(windowLength))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 1 {
+		t.Fatalf("expected one window at %v, got %d", timestamp, len(windows))
+	}
+	return windows[0]
+}
+
+func TestGetWindows(t *testing.T) {
+	windows, err := getWindows(recordedWindow.Add(-windowLength), skippedWindow.Add(windowLength))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, window := range windows {
+		got = append(got, fmt.Sprintf("%s: %d files", window.Timestamp.Format(gdeltDateTimeFormat), len(window.Files)))
+	}
+	want := []string{"20250513173000: 3 files", "20250513174500: 3 files", "20250513180000: 1 files"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got windows %v, want %v", got, want)
+	}
+	if file := windows[1].Files[kindGKG]; file.Size == 0 || file.MD5 == "" {
+		t.Errorf("expected the size and md5 of %s", file.Link)
+	}
+}
+
+func TestParseRecords(t *testing.T) {
+	selection, err := LoadSelection()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		kind  string
+		parse func(line string) (any, error)
+	}{
+		{"gkg_records", kindGKG, func(line string) (any, error) { return ParseGKGRecord(line) }},
+		{"event_records", kindExport, func(line string) (any, error) { return ParseEventRecord(line) }},
+		{"mention_records", kindMentions, func(line string) (any, error) { return ParseMentionRecord(line) }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file, err := window(t, recordedWindow).File(test.kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			zipped_file, err := openZippedFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer zipped_file.Close()
+
+			// Bad lines are kept as their error, so that the golden file shows what is skipped and why
+			var records []any
+			forEachLine(zipped_file, test.kind, func(line string) error {
+				record, err := test.parse(line)
+				if err != nil {
+					records = append(records, map[string]string{"error": err.Error()})
+					return err
+				}
+				records = append(records, record)
+				return nil
+			})
+			golden.Check(t, test.name, records)
+		})
+	}
+
+	t.Run("selected_gkg_records", func(t *testing.T) {
+		file, err := window(t, recordedWindow).File(kindGKG)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zipped_file, err := openZippedFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zipped_file.Close()
+		records, err := processGKGLines(zipped_file, selection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden.Check(t, "selected_gkg_records", records)
+	})
+}
+
+func TestSearch(t *testing.T) {
+	selection, err := LoadSelection()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		search   func(Window, Selection) (any, error)
+		window   time.Time
+		want_err error
+	}{
+		{"gkg_sources", func(w Window, s Selection) (any, error) { return SearchGKG(w, s) }, recordedWindow, nil},
+		{"event_sources", func(w Window, s Selection) (any, error) { return SearchEvents(w, s) }, recordedWindow, nil},
+		{"gkg_unpublished_window", func(w Window, s Selection) (any, error) { return SearchGKG(w, s) }, skippedWindow, errNotPublished},
+		{"events_skipped_window", func(w Window, s Selection) (any, error) { return SearchEvents(w, s) }, skippedWindow, errMissingFile},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sources, err := test.search(window(t, test.window), selection)
+			if test.want_err != nil {
+				if !errors.Is(err, test.want_err) {
+					t.Fatalf("expected %v, got %v", test.want_err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.Check(t, test.name, sources)
+		})
+	}
+}
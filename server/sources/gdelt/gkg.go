@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/geo"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// GKG 2.1 has 27 tab separated columns, see guides/gdelt and
+// http://data.gdeltproject.org/documentation/GDELT-Global_Knowledge_Graph_Codebook-V2.1.pdf
+const (
+	gkgRecordID = iota
+	gkgDate
+	gkgSourceCollection
+	gkgSourceCommonName
+	gkgDocumentIdentifier
+	gkgCounts
+	gkgCountsV21
+	gkgThemes
+	gkgEnhancedThemes
+	gkgLocations
+	gkgEnhancedLocations
+	gkgPersons
+	gkgEnhancedPersons
+	gkgOrganizations
+	gkgEnhancedOrganizations
+	gkgTone
+	gkgEnhancedDates
+	gkgGCAM
+	gkgSharingImage
+	gkgRelatedImages
+	gkgSocialImageEmbeds
+	gkgSocialVideoEmbeds
+	gkgQuotations
+	gkgAllNames
+	gkgAmounts
+	gkgTranslationInfo
+	gkgExtrasXML
+	gkgNumColumns
+)
+
+type GKGCount struct {
+	Type       string // e.g. KILL, WOUND, AFFECT, ARREST, KIDNAP
+	Number     int
+	ObjectType string // e.g. "civilians"
+	Location   *types.Location
+}
+
+type GKGTone struct {
+	Tone             float64 // positive minus negative, usually between -10 and +10
+	Positive         float64
+	Negative         float64
+	Polarity         float64
+	ActivityDensity  float64
+	SelfGroupDensity float64
+	WordCount        int
+}
+
+type GKGRecord struct {
+	ID            string
+	Date          time.Time
+	SourceName    string
+	Link          string
+	Title         string
+	Counts        []GKGCount
+	Themes        []string
+	Locations     []types.Location
+	Persons       []string
+	Organizations []string
+	Tone          GKGTone
+	GCAM          map[string]float64
+}
+
+var gkgPageTitleRegex = regexp.MustCompile("<PAGE_TITLE>(.*?)</PAGE_TITLE>")
+
+func splitNonEmpty(field string, delimiter string) []string {
+	var result []string
+	for _, part := range strings.Split(field, delimiter) {
+		if part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// parseGKGCounts reads V1COUNTS: ";"-separated blocks of
+// CountType#Number#ObjectType#LocationType#LocationFullName#LocationCountryCode#ADM1Code#Lat#Long#FeatureID
+func parseGKGCounts(field string) []GKGCount {
+	var counts []GKGCount
+	for _, block := range splitNonEmpty(field, ";") {
+		parts := strings.Split(block, "#")
+		if len(parts) < 3 {
+			continue
+		}
+		number, err := strconv.Atoi(parts[1])
+		if err != nil {
+			// Not an error; some counts don't have a number
+			continue
+		}
+		count := GKGCount{Type: parts[0], Number: number, ObjectType: parts[2]}
+		if len(parts) >= 9 {
+			locations := parseGKGLocations(strings.Join(parts[3:], "#"))
+			if len(locations) > 0 {
+				count.Location = &locations[0]
+			}
+		}
+		counts = append(counts, count)
+	}
+	return counts
+}
+
+// parseGKGTone reads V1.5TONE: Tone,Positive,Negative,Polarity,ActivityRefDensity,SelfGroupRefDensity,WordCount
+func parseGKGTone(field string) (GKGTone, error) {
+	parts := strings.Split(field, ",")
+	if len(parts) < 7 {
+		return GKGTone{}, fmt.Errorf("expected 7 tone fields, got %d", len(parts))
+	}
+	var values [6]float64
+	for i := range values {
+		value, err := strconv.ParseFloat(parts[i], 64)
+		if err != nil {
+			return GKGTone{}, fmt.Errorf("bad tone field %q: %w", parts[i], err)
+		}
+		values[i] = value
+	}
+	word_count, err := strconv.Atoi(parts[6])
+	if err != nil {
+		return GKGTone{}, fmt.Errorf("bad word count %q: %w", parts[6], err)
+	}
+	return GKGTone{
+		Tone:             values[0],
+		Positive:         values[1],
+		Negative:         values[2],
+		Polarity:         values[3],
+		ActivityDensity:  values[4],
+		SelfGroupDensity: values[5],
+		WordCount:        word_count,
+	}, nil
+}
+
+// parseGKGGCAM reads V2GCAM: ","-separated dimension:value pairs, e.g. wc:125,c2.21:4,v10.1:3.21
+func parseGKGGCAM(field string) map[string]float64 {
+	gcam := map[string]float64{}
+	for _, pair := range splitNonEmpty(field, ",") {
+		key, value_str, found := strings.Cut(pair, ":")
+		if !found {
+			continue
+		}
+		value, err := strconv.ParseFloat(value_str, 64)
+		if err != nil {
+			continue
+		}
+		gcam[key] = value
+	}
+	return gcam
+}
+
+// uniqueNames drops duplicates from V1PERSONS / V1ORGANIZATIONS, which repeat a name for every mention
+func uniqueNames(field string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range splitNonEmpty(field, ";") {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ParseGKGRecord parses one line of a GKG 2.1 file.
+// Only lines which don't have the right shape, a date or a link are errors; optional fields which can't be parsed are left empty.
+func ParseGKGRecord(line string) (GKGRecord, error) {
+	columns := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+	if len(columns) < gkgNumColumns {
+		return GKGRecord{}, fmt.Errorf("expected %d columns, got %d", gkgNumColumns, len(columns))
+	}
+
+	date, err := time.Parse("20060102150405", columns[gkgDate])
+	if err != nil {
+		return GKGRecord{}, fmt.Errorf("bad date %q: %w", columns[gkgDate], err)
+	}
+	link := columns[gkgDocumentIdentifier]
+	if !strings.HasPrefix(link, "http") {
+		// GKG also indexes e.g. JSTOR documents, identified by a DOI rather than a url
+		return GKGRecord{}, fmt.Errorf("document identifier is not a url: %q", link)
+	}
+
+	record := GKGRecord{
+		ID:            columns[gkgRecordID],
+		Date:          date,
+		SourceName:    columns[gkgSourceCommonName],
+		Link:          link,
+		Counts:        parseGKGCounts(columns[gkgCounts]),
+		Themes:        splitNonEmpty(columns[gkgThemes], ";"),
+		Locations:     parseGKGLocations(columns[gkgLocations]),
+		Persons:       uniqueNames(columns[gkgPersons]),
+		Organizations: uniqueNames(columns[gkgOrganizations]),
+		GCAM:          parseGKGGCAM(columns[gkgGCAM]),
+	}
+	if tone, err := parseGKGTone(columns[gkgTone]); err == nil {
+		record.Tone = tone
+	}
+	if matches := gkgPageTitleRegex.FindStringSubmatch(columns[gkgExtrasXML]); len(matches) >= 2 {
+		record.Title = strings.TrimSpace(html.UnescapeString(matches[1]))
+	}
+	return record, nil
+}
+
+// MaxCount returns the largest count of a given type (e.g. KILL) in the record, or nil if there is none
+func (r GKGRecord) MaxCount(count_type string) *int {
+	var max *int
+	for _, count := range r.Counts {
+		if count.Type == count_type && (max == nil || count.Number > *max) {
+			number := count.Number
+			max = &number
+		}
+	}
+	return max
+}
+
+// HasTheme checks whether the record has a theme, or a theme containing it as a "_"-separated part,
+// so that "DISEASE" matches "TAX_DISEASE_EBOLA"
+func (r GKGRecord) HasTheme(theme string) bool {
+	for _, t := range r.Themes {
+		if t == theme || strings.Contains("_"+t+"_", "_"+theme+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGKGLocations reads the V1LOCATIONS field: ";"-separated blocks of
+// Type#FullName#CountryCode#ADM1Code#Lat#Long#FeatureID, where CountryCode is a FIPS 10-4 code.
+func parseGKGLocations(field string) []types.Location {
+	var locations []types.Location
+	seen := map[string]bool{}
+	for _, block := range strings.Split(field, ";") {
+		parts := strings.Split(block, "#")
+		if len(parts) < 6 || seen[parts[1]] {
+			continue
+		}
+		lat, err_lat := strconv.ParseFloat(parts[4], 64)
+		lon, err_lon := strconv.ParseFloat(parts[5], 64)
+		if err_lat != nil || err_lon != nil {
+			// Not an error; GDELT couldn't place some locations
+			continue
+		}
+		country_code, _ := geo.ISOFromFIPS(parts[2])
+		seen[parts[1]] = true
+		locations = append(locations, types.Location{Name: parts[1], CountryCode: country_code, Lat: lat, Lon: lon})
+	}
+	return locations
+}
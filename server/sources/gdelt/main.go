@@ -1,9 +1,11 @@
 package main
 
 import (
+	"errors"
 	"io"
 	"log"
 	"os"
+	"sync"
 	"time"
 
 	"git.nunosempere.com/NunoSempere/news/lib/filters"
@@ -31,54 +33,167 @@ func main() {
 	openrouter_key := os.Getenv("OPENROUTER_API_KEY")
 	pg_database_url := os.Getenv("DATABASE_POOL_URL")
 
-	// Search gkg
+	// Which GKG records and events to look at
+	selection, err := LoadSelection()
+	if err != nil {
+		log.Fatalf("Error loading selection rules: %v", err)
+	}
+	log.Printf("Loaded %d GKG selection rules and %d event rules", len(selection.Rules), len(selection.EventRules))
+
+	feeds := []*Feed{
+		{Name: "GDELT.GKG", Kind: "gkg", Search: func(w Window) ([]types.Source, error) { return SearchGKG(w, selection) }},
+		{Name: "GDELT.Events", Kind: "events", Search: func(w Window) ([]types.Source, error) { return SearchEvents(w, selection) }},
+	}
+
+	// Reprocess a historical range and exit
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		runBackfill(os.Args[2:], feeds, openrouter_key, pg_database_url)
+		return
+	}
+
+	// Search gkg and events, catching up on any windows missed while we were down
 	ticker_gkg := time.NewTicker(15 * time.Minute)
 	defer ticker_gkg.Stop()
 	for ; true; <-ticker_gkg.C {
-		go func() {
-			// The prospector can be processing more than 2 GKG 15 minute intervals at the same time!
-			log.Println("Processing new gkg batch (this may take a min or two, as it's a large zip file)")
-			articles, err := SearchGKG()
-			if err != nil {
-				for i := 0; i < 2; i++ {
-					log.Printf("GDELT.GKG error: %v", err)
-					if i != 9 {
-						log.Printf("trying again in 30s")
-					}
-					time.Sleep(30 * time.Second)
-					articles, err = SearchGKG()
-					if err == nil {
-						break
-					}
-				}
-				if err != nil {
-					log.Printf("GDELT.GKG error: %v", err)
-					log.Printf("Tried 10 times and couldn't parse GKG zip file")
-					return
-
-				}
-			}
-			log.Printf("Batch has %d articles\n", len(articles))
-			for i, article := range articles {
-				log.Printf("\n\nArticle #%v/%v [GDELT.GKG]: %v (%v)\n", i+1, len(articles), article.Title, article.Date)
-
-				es := types.ExpandedSource{Title: article.Title, Link: article.Link, Date: article.Date}
-
-				fs := []types.Filter{
-					filters.IsFreshFilter(),
-					filters.IsDupeFilter(pg_database_url),
-					filters.IsGoodHostFilter(),
-					filters.CleanTitleFilter(),
-					filters.ExtractSummaryFilter(openrouter_key),
-					filters.CheckImportanceFilter(openrouter_key),
-				}
-				es, ok := filters.ApplyFilters(es, fs)
-				if ok {
-					pgx.SaveSource(es)
-				}
-			}
-			log.Printf("\n\nFinished processing gkg batch\n")
-		}()
+		pruneCache()
+		for _, feed := range feeds {
+			go processNewWindows(feed, openrouter_key, pg_database_url)
+		}
+	}
+
+}
+
+// Feed is one kind of GDELT data, processed window by window
+type Feed struct {
+	Name   string // for logs
+	Kind   string // key in the gdelt_progress table
+	Search func(Window) ([]types.Source, error)
+	lock   sync.Mutex
+}
+
+// processNewWindows processes the latest window, and any windows since the last one we processed
+func processNewWindows(feed *Feed, openrouter_key string, pg_database_url string) {
+	// A batch can take longer than 15 minutes; the next one waits, and then finds that the windows it would process are done
+	feed.lock.Lock()
+	defer feed.lock.Unlock()
+
+	latest, err := getLatestWindow()
+	if err != nil {
+		log.Printf("%s error: %v", feed.Name, err)
+		return
+	}
+	windows := []Window{latest}
+
+	last_processed, ok, err := getLastProcessed(pg_database_url, feed.Kind)
+	if err != nil {
+		log.Printf("%s: couldn't get progress, only processing the latest window", feed.Name)
+	} else if ok && !latest.Timestamp.After(last_processed) {
+		log.Printf("%s: latest window (%v) already processed", feed.Name, latest.Timestamp)
+		return
+	} else if ok && latest.Timestamp.Sub(last_processed) > windowLength {
+		// The file list gives us the sizes and md5s of the missed files, and which windows GDELT skipped
+		from := last_processed.Add(windowLength)
+		listed, err := getWindows(from, latest.Timestamp.Add(windowLength))
+		if err != nil {
+			log.Printf("%s: couldn't list missed windows since %v, trying again on the next tick: %v", feed.Name, from, err)
+			return
+		}
+		if len(listed) == 0 || !listed[len(listed)-1].Timestamp.Equal(latest.Timestamp) {
+			log.Printf("%s: masterfilelist.txt doesn't list %v yet, trying again on the next tick", feed.Name, latest.Timestamp)
+			return
+		}
+		log.Printf("%s: catching up on %d missed windows since %v", feed.Name, len(listed)-1, from)
+		windows = append(listed[:len(listed)-1], latest)
 	}
 
+	for _, window := range windows {
+		err := processWindow(feed, window, openrouter_key, pg_database_url, modeLive)
+		if err != nil {
+			// Try again from here on the next tick
+			return
+		}
+		setLastProcessed(pg_database_url, feed.Kind, window.Timestamp)
+	}
+}
+
+func processWindow(feed *Feed, window Window, openrouter_key string, pg_database_url string, mode batchMode) error {
+	log.Printf("Processing %s batch for %v (this may take a min or two, as these are large zip files)", feed.Name, window.Timestamp)
+	articles, err := withRetries(feed.Name, func() ([]types.Source, error) { return feed.Search(window) })
+	if errors.Is(err, errMissingFile) {
+		log.Printf("%s: skipping window: %v", feed.Name, err)
+		return nil
+	} else if errors.Is(err, errNotPublished) && time.Since(window.Timestamp) > maxPublishDelay {
+		log.Printf("%s: skipping window, its file is still missing after %v: %v", feed.Name, maxPublishDelay, err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	processArticles(articles, feed.Name, openrouter_key, pg_database_url, mode)
+	log.Printf("\n\nFinished processing %s batch for %v\n", feed.Name, window.Timestamp)
+	return nil
+}
+
+func withRetries(name string, search func() ([]types.Source, error)) ([]types.Source, error) {
+	articles, err := search()
+	for i := 0; err != nil && !errors.Is(err, errMissingFile) && i < 2; i++ {
+		log.Printf("%s error: %v", name, err)
+		log.Printf("trying again in 30s")
+		time.Sleep(30 * time.Second)
+		articles, err = search()
+	}
+	if err != nil && !errors.Is(err, errMissingFile) {
+		log.Printf("%s error: %v", name, err)
+		log.Printf("Tried 3 times and couldn't parse %s zip file", name)
+	}
+	return articles, err
+}
+
+// How a batch is processed
+type batchMode int
+
+const (
+	modeLive      batchMode = iota
+	modeBackfill            // old articles: no freshness check, nor story tracking
+	modeReprocess           // old articles, judged again: no freshness or duplicate check, and saved over what we had
+)
+
+// processArticles runs a batch through the filter pipeline
+func processArticles(articles []types.Source, name string, openrouter_key string, pg_database_url string, mode batchMode) {
+	log.Printf("Batch has %d articles\n", len(articles))
+	for i, article := range articles {
+		log.Printf("\n\nArticle #%v/%v [%s]: %v (%v)\n", i+1, len(articles), name, article.Title, article.Date)
+
+		es := types.ExpandedSource{Title: article.Title, Link: article.Link, Date: article.Date, Event: article.Event, Locations: article.Locations}
+
+		var fs []types.Filter
+		if mode == modeLive {
+			fs = append(fs, filters.IsFreshFilter())
+		}
+		if mode != modeReprocess {
+			fs = append(fs, filters.IsDupeFilter(pg_database_url))
+		}
+		fs = append(fs,
+			filters.IsGoodHostFilter(),
+			filters.CleanTitleFilter(),
+			filters.ExtractSummaryOrGistFilter(openrouter_key),
+			filters.WithRules(filters.CheckImportanceFilter(openrouter_key)),
+			filters.TranslateFilter(openrouter_key),
+			filters.ExtractEventFilter(openrouter_key),
+			filters.GeotagFilter(),
+		)
+		// Old articles would move live stories back to past states
+		if mode == modeLive {
+			fs = append(fs, filters.TrackStoryFilter(openrouter_key, pg_database_url))
+		}
+		es, ok := filters.ApplyFilters(es, fs)
+		switch {
+		case ok && mode == modeReprocess:
+			pgx.ResaveSource(es)
+		case ok:
+			pgx.SaveSource(es)
+		case mode == modeReprocess && es.ImportanceReasoning != "":
+			// Dropped by the new judgement; articles which were kept before are marked as such
+			pgx.UpdateImportance(es)
+		}
+	}
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// getLastProcessed returns the last window processed for a kind of file, and false if there is none yet
+func getLastProcessed(database_url string, kind string) (time.Time, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return time.Time{}, false, err
+	}
+	defer conn.Close(context.Background())
+
+	var last_processed time.Time
+	err = conn.QueryRow(ctx, `SELECT last_processed FROM gdelt_progress WHERE kind = $1`, kind).Scan(&last_processed)
+	if err == pgx.ErrNoRows {
+		return time.Time{}, false, nil
+	} else if err != nil {
+		log.Printf("Error getting gdelt progress: %v\n", err)
+		return time.Time{}, false, err
+	}
+	return last_processed, true, nil
+}
+
+// setLastProcessed records that a window was processed. Progress only moves forward.
+func setLastProcessed(database_url string, kind string, window time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, `
+		INSERT INTO gdelt_progress (kind, last_processed)
+		VALUES ($1, $2)
+		ON CONFLICT (kind) DO UPDATE
+		SET last_processed = GREATEST(gdelt_progress.last_processed, EXCLUDED.last_processed),
+		    updated_at = CURRENT_TIMESTAMP
+	`, kind, window)
+	if err != nil {
+		log.Printf("Error saving gdelt progress: %v\n", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+)
+
+// Which GKG records and Events rows are worth sending through the (expensive) filter pipeline.
+// A record is selected if it matches any rule; a rule matches if all the conditions it sets hold.
+
+const selectionFile = "selection.json"
+
+type SelectionRule struct {
+	Name       string   `json:"name"`
+	ThemesAny  []string `json:"themes_any,omitempty"`  // at least one of these GKG themes
+	MinKilled  *int     `json:"min_killed,omitempty"`  // largest KILL count strictly above this
+	MinWounded *int     `json:"min_wounded,omitempty"` // largest WOUND count strictly above this
+	MaxTone    *float64 `json:"max_tone,omitempty"`    // tone at or below this; GKG tone is negative for bad news
+}
+
+// EventRule selects rows of the Events export in the same way
+type EventRule struct {
+	Name         string   `json:"name"`
+	RootCodes    []string `json:"root_codes,omitempty"`    // CAMEO root codes, e.g. "18" (assault), "19" (fight), "20" (mass violence)
+	MaxGoldstein *float64 `json:"max_goldstein,omitempty"` // Goldstein scale at or below this; -10 is the most destabilizing
+	MinMentions  *int     `json:"min_mentions,omitempty"`  // NumMentions at or above this
+	RootOnly     bool     `json:"root_only,omitempty"`     // only events mentioned in an article's lead paragraph
+}
+
+type Selection struct {
+	Rules      []SelectionRule `json:"rules"`
+	EventRules []EventRule     `json:"event_rules"`
+	MaxEvents  int             `json:"max_events"` // per 15 minute window, after ranking by mentions
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+// The criteria we used before selection rules were configurable
+var defaultSelection = Selection{
+	Rules: []SelectionRule{
+		{Name: "mass casualties", MinKilled: intPtr(100)},
+		{Name: "mass injuries", MinWounded: intPtr(1000)},
+	},
+	EventRules: []EventRule{
+		{Name: "mass violence", RootCodes: []string{"18", "19", "20"}, MaxGoldstein: floatPtr(-9), RootOnly: true},
+	},
+	MaxEvents: 20,
+}
+
+func LoadSelection() (Selection, error) {
+	data, err := os.ReadFile(selectionFile)
+	if os.IsNotExist(err) {
+		log.Printf("No %s found, using default selection rules", selectionFile)
+		return defaultSelection, nil
+	} else if err != nil {
+		return Selection{}, err
+	}
+
+	var selection Selection
+	if err := json.Unmarshal(data, &selection); err != nil {
+		return Selection{}, fmt.Errorf("parsing %s: %w", selectionFile, err)
+	}
+	for i, rule := range selection.Rules {
+		if len(rule.ThemesAny) == 0 && rule.MinKilled == nil && rule.MinWounded == nil && rule.MaxTone == nil {
+			return Selection{}, fmt.Errorf("rule #%d (%q) in %s has no conditions, and would select everything", i+1, rule.Name, selectionFile)
+		}
+	}
+	for i, rule := range selection.EventRules {
+		if len(rule.RootCodes) == 0 && rule.MaxGoldstein == nil && rule.MinMentions == nil {
+			return Selection{}, fmt.Errorf("event rule #%d (%q) in %s has no conditions, and would select everything", i+1, rule.Name, selectionFile)
+		}
+	}
+	return selection, nil
+}
+
+func (rule SelectionRule) Matches(record GKGRecord) bool {
+	if len(rule.ThemesAny) > 0 {
+		has_theme := false
+		for _, theme := range rule.ThemesAny {
+			if record.HasTheme(theme) {
+				has_theme = true
+				break
+			}
+		}
+		if !has_theme {
+			return false
+		}
+	}
+	if rule.MinKilled != nil {
+		killed := record.MaxCount("KILL")
+		if killed == nil || *killed <= *rule.MinKilled {
+			return false
+		}
+	}
+	if rule.MinWounded != nil {
+		wounded := record.MaxCount("WOUND")
+		if wounded == nil || *wounded <= *rule.MinWounded {
+			return false
+		}
+	}
+	if rule.MaxTone != nil && record.Tone.Tone > *rule.MaxTone {
+		return false
+	}
+	return true
+}
+
+// Select returns the name of the first rule a record matches
+func (selection Selection) Select(record GKGRecord) (string, bool) {
+	for _, rule := range selection.Rules {
+		if rule.Matches(record) {
+			return rule.Name, true
+		}
+	}
+	return "", false
+}
+
+func (rule EventRule) Matches(event EventRecord) bool {
+	if len(rule.RootCodes) > 0 && !slices.Contains(rule.RootCodes, event.RootCode) {
+		return false
+	}
+	if rule.MaxGoldstein != nil && event.Goldstein > *rule.MaxGoldstein {
+		return false
+	}
+	if rule.MinMentions != nil && event.NumMentions < *rule.MinMentions {
+		return false
+	}
+	if rule.RootOnly && !event.IsRootEvent {
+		return false
+	}
+	return true
+}
+
+// SelectEvent returns the name of the first event rule an event matches
+func (selection Selection) SelectEvent(event EventRecord) (string, bool) {
+	for _, rule := range selection.EventRules {
+		if rule.Matches(event) {
+			return rule.Name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/extractors"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+// We crawl these sites, rather than follow a few links, so we go slowly and respect their robots.txt
+var crawlClient = web.NewClient(web.Config{RequestsPerSec: 1.0 / 6, Burst: 1, RespectRobots: true})
+
+// ExtractArticle fetches an article page and applies the lib/extractors rule for its domain
+func ExtractArticle(site Site, link string) (Article, error) {
+	rule, ok := extractors.RuleFor(link)
+	if !ok {
+		return Article{}, extractors.ErrNoRule
+	}
+	html, err := crawlClient.Get(link)
+	if err != nil {
+		return Article{}, err
+	}
+	extracted, err := extractors.Extract(html, rule)
+	if err != nil {
+		return Article{}, err
+	}
+	title := strings.TrimSpace(extracted.Title)
+	title, _, _ = strings.Cut(title, "\n")
+
+	date, has_date := ExtractDateFromURL(link)
+	if extracted.HasDate {
+		date, has_date = extracted.Date, true
+	}
+	return Article{Site: site.Name, Origin: site.Origin, Link: link, Title: title, Content: extracted.Body, Date: date, HasDate: has_date}, nil
+}
+
+// DiscoverArticleUrls finds the article links in a site's start pages for now, without repeats
+func DiscoverArticleUrls(site Site, now time.Time) []string {
+	var article_urls []string
+	seen := map[string]bool{}
+	for _, start_url := range site.StartPages(now) {
+		base, _ := url.Parse(start_url)
+		html, err := crawlClient.Get(start_url)
+		if err != nil {
+			log.Printf("Error fetching %s: %v", start_url, err)
+			continue
+		}
+		hrefs, err := web.GetUrls(bytes.NewReader(html))
+		if err != nil {
+			log.Printf("Error parsing %s: %v", start_url, err)
+			continue
+		}
+		for _, href := range hrefs {
+			ref, err := url.Parse(strings.TrimSpace(href))
+			if err != nil {
+				continue
+			}
+			link := base.ResolveReference(ref)
+			link.Fragment = ""
+			if absolute := link.String(); site.IsArticle(absolute) && !seen[absolute] {
+				seen[absolute] = true
+				article_urls = append(article_urls, absolute)
+			}
+		}
+	}
+	log.Printf("Found %d article links on %s", len(article_urls), site.Name)
+	return article_urls
+}
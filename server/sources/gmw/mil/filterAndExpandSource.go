@@ -1,12 +1,10 @@
 package main
 
 import (
-	"log"
 	"regexp"
 	"time"
 
 	"git.nunosempere.com/NunoSempere/news/lib/filters"
-	"git.nunosempere.com/NunoSempere/news/lib/llm"
 	"git.nunosempere.com/NunoSempere/news/lib/types"
 )
 
@@ -33,65 +31,27 @@ func IsWithinTwoDays(articleDate time.Time) bool {
 	return articleDate.After(oneWeekAgo)
 }
 
-func TranslateArticle(article GmwMilSource, openrouter_token string) (GmwMilSourceTranslated, error) {
-	translated_title, err := llm.TranslateString(article.Title, openrouter_token)
-	if err != nil {
-		return GmwMilSourceTranslated{}, err
-	}
-	translated_content, err := llm.TranslateString(article.Content, openrouter_token)
-	if err != nil {
-		return GmwMilSourceTranslated{}, err
-	}
-	return GmwMilSourceTranslated{
-		Link:            article.Link,
-		OriginalTitle:   article.Title,
-		OriginalContent: article.Content,
-		EnglishTitle:    translated_title,
-		EnglishContent:  translated_content,
-	}, nil
-}
-
-func FilterAndExpandSource(article GmwMilSource, openrouter_key string, database_url string) (types.ExpandedSource, bool) {
-	// is there some parsimonious way of doing this in a more functional way that reuses code? Probably not?
-
-	date, _ := ExtractDateFromURL(article.Link)
-	is_dupe := filters.IsDupe(types.Source{Title: article.Title, Link: article.Link}, database_url)
-	if is_dupe {
-		return types.ExpandedSource{}, false
-	}
-
-	gmw, err := TranslateArticle(article, openrouter_key)
-	if err != nil {
-		log.Printf("%v", err)
-		return types.ExpandedSource{}, false
-	}
-	log.Printf("\nTranslated title: %s", gmw.EnglishTitle)
-
-	expanded_source := types.ExpandedSource{
-		Title: gmw.EnglishTitle,
-		Link:  gmw.Link,
-		Date:  date,
-	}
-
-	summary, err := llm.Summarize(gmw.EnglishContent+"\n\nWhen summarizing a Chinese article, give the gist in idiomatic English, rather than selecting the most important phrases in Chinese", openrouter_key)
-	if err != nil {
-		log.Printf("%v", err)
-		return expanded_source, false
-	}
-	expanded_source.Summary = summary
-	log.Printf("\nSummary: %s", expanded_source.Summary)
-
-	existential_importance_snippet := "# " + expanded_source.Title + "\n\n" + summary
-	existential_importance_box, err := llm.CheckExistentialImportanceChina(existential_importance_snippet, openrouter_key)
-	if err != nil || existential_importance_box == nil {
-		log.Printf("%v", err)
-		return expanded_source, false
-	}
-	expanded_source.ImportanceBool = existential_importance_box.ExistentialImportanceBool
-	expanded_source.ImportanceReasoning = existential_importance_box.ExistentialImportanceReasoning
-
-	log.Printf("Importance bool: %t", expanded_source.ImportanceBool)
-	log.Printf("Importance reasoning: %s", expanded_source.ImportanceReasoning)
-
-	return expanded_source, expanded_source.ImportanceBool
+// FilterAndExpandSource checks the importance of an article from its original title and a cheap gist,
+// and only translates the articles which pass, see lib/filters/translate.go
+func FilterAndExpandSource(article Article, openrouter_key string, database_url string) (types.ExpandedSource, bool) {
+	es := types.ExpandedSource{
+		Title:         article.Title,
+		Link:          article.Link,
+		Date:          article.Date,
+		Origin:        article.Origin,
+		OriginalTitle: article.Title,
+		OriginalText:  article.Content,
+	}
+
+	fs := []types.Filter{
+		filters.IsDupeFilter(database_url),
+		filters.DetectLanguageFilter(),
+		filters.GistFilter(openrouter_key),
+		filters.WithRules(filters.ProfileImportanceFilter("china", openrouter_key)),
+		filters.TranslateFilter(openrouter_key),
+		filters.ExtractEventFilter(openrouter_key),
+		filters.GeotagFilter(),
+		filters.TrackStoryFilter(openrouter_key, database_url),
+	}
+	return filters.ApplyFilters(es, fs)
 }
@@ -3,15 +3,21 @@ package main
 import (
 	"io"
 	"log"
-	"math/rand"
 	"os"
-	"slices"
 	"time"
 
 	"git.nunosempere.com/NunoSempere/news/lib/pgx"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
 	"github.com/joho/godotenv"
 )
 
+// Each crawl only fetches pages we haven't read yet, so crawling often is cheap
+const crawlInterval = 2 * time.Hour
+
+// Articles which failed are read again on the next crawl, until they go stale; those without a date never do,
+// so they get this many tries
+const maxUndatedAttempts = 3
+
 func main() {
 
 	// Initialize logging
@@ -31,50 +37,76 @@ func main() {
 	openrouter_key := os.Getenv("OPENROUTER_API_KEY")
 	pg_database_url := os.Getenv("DATABASE_POOL_URL")
 
-	for {
-		frontpage_articles, err := GetFrontpageUrls()
-		if err != nil {
-			log.Print(err)
-		}
-		// log.Println(frontpage_articles)
-		titles := []string{}
-		for _, url := range frontpage_articles {
-			log.Printf("Url: %s", url)
-
-			// filter here so as to not fetch full article if not necessary
-			date, hasDate := ExtractDateFromURL(url)
-			if hasDate && !IsWithinTwoDays(date) {
-				log.Printf("Article is stale")
-				continue
-			}
+	sites, err := LoadSites()
+	if err != nil {
+		log.Fatalf("Error loading sites: %v", err)
+	}
 
-			ms := 5000 + int64(2000*rand.Float32())
-			time.Sleep(time.Duration(ms) * time.Millisecond)
-			article, err := ExtractFrontpageArticle(url)
-			if err != nil {
-				log.Print(err)
-				// log.Print(err)
-				continue
-			}
+	attempts := map[string]int{}
+	// tryAgain counts a failed attempt at an article, and is false once an undated one has had its tries
+	tryAgain := func(url string, has_date bool) bool {
+		attempts[url]++
+		if has_date || attempts[url] < maxUndatedAttempts {
+			return true
+		}
+		log.Printf("Giving up on undated article after %d attempts", attempts[url])
+		delete(attempts, url)
+		return false
+	}
 
-			// All articles are duplicated, but with different underlying urls :(
-			if slices.Contains(titles, article.Title) {
-				// log.Println("Article is a duplicate")
-				continue
-			}
-			titles = append(titles, article.Title)
+	for {
+		for _, site := range sites {
+			log.Printf("Crawling %s", site.Name)
+			for _, url := range DiscoverArticleUrls(site, time.Now()) {
+				if isVisited(pg_database_url, url) {
+					continue
+				}
+				log.Printf("Url: %s", url)
+				// filter here so as to not fetch full article if not necessary
+				date, hasDate := ExtractDateFromURL(url)
+				if hasDate && !IsWithinTwoDays(date) {
+					log.Printf("Article is stale")
+					continue
+				}
 
-			log.Printf("Title: %s", article.Title)
+				article, err := ExtractArticle(site, url)
+				if err != nil {
+					log.Print(err)
+					if !web.IsTemporary(err) || !tryAgain(url, hasDate) {
+						markVisited(pg_database_url, url, site.Name, "")
+					}
+					continue
+				}
+				title_key := NormalizeTitle(article.Title)
+				if title_key != "" {
+					if seen_url, seen := visitedTitle(pg_database_url, title_key); seen {
+						log.Printf("Article is a duplicate of %s", seen_url)
+						markVisited(pg_database_url, url, site.Name, title_key)
+						continue
+					}
+				}
+				if article.HasDate && !IsWithinTwoDays(article.Date) {
+					log.Printf("Article is stale")
+					markVisited(pg_database_url, url, site.Name, title_key)
+					continue
+				}
 
-			es, ok := FilterAndExpandSource(article, openrouter_key, pg_database_url)
-			// useful for filtering duplicates within the same batch
-			// eventually I'd need to have a longer database of titles
-			if ok {
-				log.Println(es.Summary)
-				pgx.SaveSource(es)
+				log.Printf("Title: %s", article.Title)
+				es, ok := FilterAndExpandSource(article, openrouter_key, pg_database_url)
+				// Articles dropped because e.g. the LLM was down are read again on the next crawl
+				if !ok && es.Failed && tryAgain(url, hasDate || article.HasDate) {
+					log.Printf("Will try again on the next crawl")
+					continue
+				}
+				delete(attempts, url)
+				markVisited(pg_database_url, url, site.Name, title_key)
+				if ok {
+					log.Println(es.Summary)
+					pgx.SaveSource(es)
+				}
 			}
 		}
-		log.Printf("Finished batch. Continuing in 12 hours")
-		time.Sleep(12 * time.Hour)
+		log.Printf("Finished crawl. Continuing in %v", crawlInterval)
+		time.Sleep(crawlInterval)
 	}
 }
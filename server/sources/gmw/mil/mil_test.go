@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/golden"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+// Fixtures are recorded from gmw/, where make record runs. They are synthetic for now; see ../fixtures/README.md
+func TestMain(m *testing.M) {
+	web.SetMode(web.ModeReplay, "../fixtures/web")
+	os.Exit(m.Run())
+}
+
+// site returns an entry of ../sites.json, which LoadSites reads from gmw/
+func site(t *testing.T, name string) Site {
+	t.Helper()
+	sites, err := loadSites("../sites.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, site := range sites {
+		if site.Name == name {
+			return site
+		}
+	}
+	t.Fatalf("no site %s in ../sites.json", name)
+	return Site{}
+}
+
+func TestDiscoverArticleUrls(t *testing.T) {
+	urls := DiscoverArticleUrls(site(t, "gmw"), time.Date(2025, 5, 13, 10, 0, 0, 0, time.UTC))
+	golden.Check(t, "article_urls", urls)
+}
+
+func TestExtractArticle(t *testing.T) {
+	tests := []struct {
+		name     string
+		link     string
+		want_err bool
+	}{
+		{"drill", "https://mil.gmw.cn/2025-05/13/content_38032114.htm", false},
+		{"training", "https://mil.gmw.cn/2025-05/12/content_38029876.htm", false},
+		{"no_body", "https://mil.gmw.cn/2025-05/12/content_38029999.htm", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			article, err := ExtractArticle(site(t, "gmw"), test.link)
+			if test.want_err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", article)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.Check(t, test.name, article)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/config"
+	"git.nunosempere.com/NunoSempere/news/lib/extractors"
+)
+
+const sitesFile = "sites.json"
+
+// Sites publish in Beijing time, which matters for the dates in their urls
+var beijing = time.FixedZone("CST", 8*60*60)
+
+// Site is one entry in sites.json. Article pages are extracted with the rule for their domain in lib/extractors.
+type Site struct {
+	Name           string   `json:"name"`
+	Origin         string   `json:"origin"`          // saved with each source, e.g. "PLA Daily"
+	StartURLs      []string `json:"start_urls"`      // pages to look for article links in; {2006-01/02} is replaced by today's date in that Go layout
+	ArticlePattern string   `json:"article_pattern"` // regex which article links, once made absolute, match
+	Disabled       bool     `json:"disabled,omitempty"`
+	article_regex  *regexp.Regexp
+}
+
+var date_placeholder = regexp.MustCompile(`\{([^}]+)\}`)
+
+// StartPages fills in the date in the site's start urls
+func (site Site) StartPages(now time.Time) []string {
+	var pages []string
+	for _, start_url := range site.StartURLs {
+		pages = append(pages, date_placeholder.ReplaceAllStringFunc(start_url, func(placeholder string) string {
+			return now.In(beijing).Format(placeholder[1 : len(placeholder)-1])
+		}))
+	}
+	return pages
+}
+
+func (site Site) IsArticle(link string) bool {
+	return site.article_regex.MatchString(link)
+}
+
+// LoadSites reads the sites to crawl from sites.json. Each start page needs an extractor rule in lib/extractors.
+func LoadSites() ([]Site, error) {
+	return loadSites(sitesFile)
+}
+
+func loadSites(file string) ([]Site, error) {
+	return config.LoadList(file, "site", validateSite, func(site Site) bool { return site.Disabled })
+}
+
+func validateSite(site *Site) ([]string, error) {
+	if site.Name == "" || site.Origin == "" {
+		return nil, fmt.Errorf("needs a name and an origin")
+	}
+	if len(site.StartURLs) == 0 {
+		return nil, fmt.Errorf("%s has no start_urls", site.Name)
+	}
+	for _, start_url := range site.StartPages(time.Now()) {
+		if !config.IsURL(start_url) {
+			return nil, fmt.Errorf("%s: %q is not a url", site.Name, start_url)
+		}
+		if _, ok := extractors.RuleFor(start_url); !ok {
+			return nil, fmt.Errorf("%s: no extractor rule for %s", site.Name, start_url)
+		}
+	}
+	var err error
+	site.article_regex, err = regexp.Compile(site.ArticlePattern)
+	if err != nil || site.ArticlePattern == "" {
+		return nil, fmt.Errorf("%s: bad article_pattern %q: %v", site.Name, site.ArticlePattern, err)
+	}
+	return []string{site.Name}, nil
+}
@@ -1,15 +1,13 @@
 package main
 
-type GmwMilSource struct {
+import "time"
+
+type Article struct {
+	Site    string // name in sites.json
+	Origin  string
 	Link    string
 	Title   string
 	Content string
-}
-
-type GmwMilSourceTranslated struct {
-	Link            string
-	OriginalTitle   string
-	OriginalContent string
-	EnglishTitle    string
-	EnglishContent  string
+	Date    time.Time
+	HasDate bool
 }
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+/* The crawler remembers every article page it has read in the crawler_visited table,
+so that it only fetches new ones, even across restarts. The same article often appears under
+several urls, and on several sites, so we also remember its normalized title. */
+
+// NormalizeTitle makes the titles of the same article compare equal: it folds full-width
+// letters and digits to their usual width, lowercases, and drops spaces and punctuation,
+// e.g. "中国海军　“０９２”舰访问" and "中国海军“092”舰访问" become "中国海军092舰访问"
+func NormalizeTitle(title string) string {
+	title = norm.NFKC.String(width.Fold.String(title))
+	var key strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			key.WriteRune(r)
+		}
+	}
+	return key.String()
+}
+
+// isVisited is true if the crawler has already read this url
+func isVisited(database_url string, link string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return false
+	}
+	defer conn.Close(context.Background())
+
+	var exists bool
+	err = conn.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM crawler_visited WHERE url = $1)`, link).Scan(&exists)
+	if err != nil {
+		log.Printf("Error checking visited urls: %v\n", err)
+		return false
+	}
+	return exists
+}
+
+// visitedTitle returns the url under which the crawler read an article with this title key in the last few days, if any.
+// Sites rerun old headlines, e.g. for anniversaries, so older titles don't count as duplicates.
+func visitedTitle(database_url string, title_key string) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return "", false
+	}
+	defer conn.Close(context.Background())
+
+	var link string
+	err = conn.QueryRow(ctx, `
+		SELECT url FROM crawler_visited
+		WHERE title_key = $1 AND title_key <> '' AND visited_at > CURRENT_TIMESTAMP - INTERVAL '3 days'
+		LIMIT 1
+	`, title_key).Scan(&link)
+	if err == pgx.ErrNoRows {
+		return "", false
+	} else if err != nil {
+		log.Printf("Error checking visited titles: %v\n", err)
+		return "", false
+	}
+	return link, true
+}
+
+// markVisited records that the crawler has read a url, with the title key of its article if it had one
+func markVisited(database_url string, link string, site string, title_key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, `
+		INSERT INTO crawler_visited (url, site, title_key)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (url) DO NOTHING
+	`, link, site, title_key)
+	if err != nil {
+		log.Printf("Error saving visited url: %v\n", err)
+	}
+}
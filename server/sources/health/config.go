@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"git.nunosempere.com/NunoSempere/news/lib/config"
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+)
+
+const bulletinsFile = "bulletins.json"
+
+// Profile for bulletins which don't name one. Unlike in the feeds source, it isn't detected,
+// because every item of these bulletins is about an outbreak.
+const defaultProfile = "outbreak-bulletins"
+
+// How bulletins are read: "feed" for RSS, Atom or JSON Feed, "who-don" for the WHO Disease Outbreak News API
+var bulletin_kinds = []string{"feed", "who-don"}
+
+// Bulletin is one entry in bulletins.json
+type Bulletin struct {
+	URL               string `json:"url"`
+	Origin            string `json:"origin"` // saved with each source, e.g. "WHO Disease Outbreak News"
+	Kind              string `json:"kind"`
+	MaxAgeHours       int    `json:"max_age_hours,omitempty"`      // skip items older than this; 0 means the usual 15 days
+	ContentSelector   string `json:"content_selector,omitempty"`   // for feeds: css selector for the article body; readability if empty or not found
+	ImportancePrompt  string `json:"importance_prompt,omitempty"`  // extra context about this bulletin for the importance check
+	ImportanceProfile string `json:"importance_profile,omitempty"` // see lib/llm/profiles.json; defaultProfile if empty
+	Disabled          bool   `json:"disabled,omitempty"`
+}
+
+// LoadBulletins reads the outbreak bulletins to follow from bulletins.json
+func LoadBulletins() ([]Bulletin, error) {
+	return config.LoadList(bulletinsFile, "bulletin", validateBulletin, func(bulletin Bulletin) bool { return bulletin.Disabled })
+}
+
+func validateBulletin(bulletin *Bulletin) ([]string, error) {
+	if !config.IsURL(bulletin.URL) {
+		return nil, fmt.Errorf("%q is not a url", bulletin.URL)
+	}
+	if bulletin.Origin == "" {
+		return nil, fmt.Errorf("%s has no origin", bulletin.URL)
+	}
+	if !slices.Contains(bulletin_kinds, bulletin.Kind) {
+		return nil, fmt.Errorf("%s has an unknown kind %q", bulletin.URL, bulletin.Kind)
+	}
+	if bulletin.MaxAgeHours < 0 {
+		return nil, fmt.Errorf("%s has a negative max_age_hours", bulletin.URL)
+	}
+	if bulletin.ImportanceProfile == "" {
+		bulletin.ImportanceProfile = defaultProfile
+	}
+	if _, ok := llm.GetProfile(bulletin.ImportanceProfile); !ok {
+		return nil, fmt.Errorf("%s has an unknown importance_profile %q", bulletin.URL, bulletin.ImportanceProfile)
+	}
+	return []string{bulletin.URL}, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feeds"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"github.com/PuerkitoBio/goquery"
+)
+
+const whoDONItemURL = "https://www.who.int/emergencies/disease-outbreak-news/item/"
+
+// WHODONResponse is a page of the WHO Disease Outbreak News API, whose sections are html
+type WHODONResponse struct {
+	Value []struct {
+		Title                  string `json:"Title"`
+		UrlName                string `json:"UrlName"`
+		PublicationDateAndTime string `json:"PublicationDateAndTime"`
+		Summary                string `json:"Summary"`
+		Overview               string `json:"Overview"`
+		Epidemiology           string `json:"Epidemiology"`
+		Assessment             string `json:"Assessment"`
+	} `json:"value"`
+}
+
+// htmlText gets the text of an html fragment, one paragraph per line
+func htmlText(fragment string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+	if err != nil {
+		return ""
+	}
+	var paragraphs []string
+	doc.Find("p, li, h2, h3, h4").Each(func(_ int, s *goquery.Selection) {
+		if text := strings.TrimSpace(s.Text()); text != "" && s.Find("p, li").Length() == 0 {
+			paragraphs = append(paragraphs, text)
+		}
+	})
+	if len(paragraphs) == 0 {
+		return strings.TrimSpace(doc.Text())
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+// fetchWHODON gets the latest Disease Outbreak News items, with their overview, epidemiology and assessment as summary
+func fetchWHODON(url string) ([]feeds.Item, error) {
+	resp, err := web.DefaultClient.Open(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response WHODONResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	var items []feeds.Item
+	for _, don := range response.Value {
+		if don.Title == "" || don.UrlName == "" {
+			continue
+		}
+		var sections []string
+		for _, section := range []struct{ name, html string }{
+			{"Overview", don.Overview},
+			{"Description of the situation", don.Epidemiology},
+			{"WHO risk assessment", don.Assessment},
+		} {
+			if text := htmlText(section.html); text != "" {
+				sections = append(sections, "## "+section.name+"\n\n"+text)
+			}
+		}
+		if len(sections) == 0 {
+			sections = append(sections, htmlText(don.Summary))
+		}
+
+		item := feeds.Item{
+			Title:   strings.TrimSpace(don.Title),
+			Link:    whoDONItemURL + don.UrlName,
+			Summary: strings.Join(sections, "\n\n"),
+		}
+		item.Date, err = feeds.ParseDate(don.PublicationDateAndTime)
+		if err != nil {
+			log.Printf("Skipping %q, with malformed date: %v", don.Title, err)
+			continue
+		}
+		item.HasDate = true
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// FetchItems gets the latest items of a bulletin
+func FetchItems(bulletin Bulletin) ([]feeds.Item, error) {
+	switch bulletin.Kind {
+	case "feed":
+		// Feeds that haven't changed since last time come back empty (304 Not Modified)
+		return feeds.Fetch(bulletin.URL)
+	case "who-don":
+		return fetchWHODON(bulletin.URL)
+	}
+	return nil, fmt.Errorf("unknown kind %q", bulletin.Kind)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"git.nunosempere.com/NunoSempere/news/lib/feeds"
+	"git.nunosempere.com/NunoSempere/news/lib/filters"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// FilterAndExpandSource extracts the pathogen, places and case counts of a bulletin item before checking its importance,
+// so that the check can weigh signs of human-to-human transmission and novel pathogens
+func FilterAndExpandSource(item feeds.Item, bulletin Bulletin, openrouter_key string, database_url string) (types.ExpandedSource, bool) {
+	es := types.ExpandedSource{
+		Title:  item.Title,
+		Link:   item.Link,
+		Date:   item.Date,
+		Origin: bulletin.Origin,
+	}
+	// The WHO API gives the whole report, so there is no page to fetch
+	if bulletin.Kind == "who-don" {
+		es.Content = item.Summary
+	}
+
+	fs := []types.Filter{
+		filters.MaxAgeFilter(bulletin.MaxAgeHours),
+		filters.IsDupeFilter(database_url),
+		filters.CleanTitleFilter(),
+		filters.SelectorSummaryFilter(bulletin.ContentSelector, item.Summary, openrouter_key),
+		filters.ExtractOutbreakFilter(openrouter_key),
+		filters.WithRules(filters.ProfileImportanceFilterWithNote(bulletin.ImportanceProfile, importanceNote(bulletin), openrouter_key)),
+		filters.TranslateFilter(openrouter_key),
+		filters.GeotagFilter(),
+		filters.TrackStoryFilter(openrouter_key, database_url),
+	}
+	return filters.ApplyFilters(es, fs)
+}
+
+// importanceNote tells the importance check what matters in the bulletin, if its config says
+func importanceNote(bulletin Bulletin) string {
+	if bulletin.ImportancePrompt == "" {
+		return ""
+	}
+	return "Note about this source (" + bulletin.Origin + "): " + bulletin.ImportancePrompt
+}
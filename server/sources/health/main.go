@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/pgx"
+	"github.com/joho/godotenv"
+)
+
+const pollInterval = 1 * time.Hour
+
+func main() {
+	// Set up logging
+	logFile, err := os.OpenFile("v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Load environment variables
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openrouter_key := os.Getenv("OPENROUTER_API_KEY")
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	bulletins, err := LoadBulletins()
+	if err != nil {
+		log.Fatalf("Error loading bulletins: %v", err)
+	}
+	log.Printf("Loaded %d bulletins", len(bulletins))
+
+	// The WHO API returns its latest items every time, so remember which we have processed.
+	// Kept items are also caught by the duplicate filter after a restart.
+	processed := map[string]bool{}
+	for {
+		for _, bulletin := range bulletins {
+			items, err := FetchItems(bulletin)
+			if err != nil {
+				log.Printf("Error fetching %s: %v", bulletin.Origin, err)
+				continue
+			}
+			log.Printf("Found %d %s items", len(items), bulletin.Origin)
+
+			for _, item := range items {
+				if processed[item.Link] {
+					continue
+				}
+				log.Printf("\nProcessing %s item: %s (%v)", bulletin.Origin, item.Title, item.Date)
+
+				es, ok := FilterAndExpandSource(item, bulletin, openrouter_key, pg_database_url)
+				if !ok && es.Failed {
+					log.Printf("Will try again on the next poll")
+					continue
+				}
+				processed[item.Link] = true
+				if ok {
+					pgx.SaveSource(es)
+				}
+			}
+		}
+
+		log.Printf("Finished processing bulletins, sleeping for %v", pollInterval)
+		time.Sleep(pollInterval)
+	}
+}
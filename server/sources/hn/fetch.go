@@ -3,19 +3,23 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"git.nunosempere.com/NunoSempere/news/lib/web"
 	"log"
+	"sort"
+	"strings"
 	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"github.com/PuerkitoBio/goquery"
 )
 
 func fetchPage(page int, startTime, endTime int64) (*HNResponse, error) {
-	url := fmt.Sprintf("http://hn.algolia.com/api/v1/search_by_date?tags=story&numericFilters=created_at_i>%d,created_at_i<%d&page=%d",
+	url := fmt.Sprintf("http://hn.algolia.com/api/v1/search_by_date?tags=story&numericFilters=created_at_i>%d,created_at_i<%d&page=%d&hitsPerPage=100",
 		startTime,
 		endTime,
 		page)
-	
+
 	log.Printf("Fetching HN feed page %d: %s", page, url)
-	
+
 	bytes, err := web.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching HN feed: %v", err)
@@ -29,36 +33,86 @@ func fetchPage(page int, startTime, endTime int64) (*HNResponse, error) {
 	return &response, nil
 }
 
-func FetchFeed() ([]HNHit, error) {
-	// Calculate time range for the last hour
-	now := time.Now()
-	oneHourAgo := now.Add(-1 * time.Hour)
-	twoHoursAgo := now.Add(-2 * time.Hour) // give time to accumulate upvotes
-	
+// FetchFeed gets every story submitted in the window before now, with its current points and comments
+func FetchFeed(now time.Time, window time.Duration) ([]HNHit, error) {
+	start := now.Add(-window)
+
 	var allSources []HNHit
-	currentPage := 0
-	
-	// Fetch first page to get pagination info
-	response, err := fetchPage(currentPage, twoHoursAgo.Unix(), oneHourAgo.Unix())
+	response, err := fetchPage(0, start.Unix(), now.Unix())
 	if err != nil {
 		return nil, err
 	}
-
 	log.Printf("Found %d HN stories across %d pages", response.NbHits, response.NbPages)
+	allSources = append(allSources, response.Hits...)
 
-	// Process all pages
-	for currentPage = 0; currentPage < response.NbPages; currentPage++ {
-		if currentPage > 0 {
-			response, err = fetchPage(currentPage, oneHourAgo.Unix(), now.Unix())
-			if err != nil {
-				log.Printf("Error fetching page %d: %v", currentPage, err)
-				continue
-			}
+	// Later pages use the same window as the first, so that pages line up
+	for currentPage := 1; currentPage < response.NbPages; currentPage++ {
+		page, err := fetchPage(currentPage, start.Unix(), now.Unix())
+		if err != nil {
+			log.Printf("Error fetching page %d: %v", currentPage, err)
+			continue
 		}
-		allSources = append(allSources, response.Hits...)
+		allSources = append(allSources, page.Hits...)
 	}
 
 	log.Printf("Processed %d valid HN stories", len(allSources))
 	return allSources, nil
 }
 
+const (
+	maxComments      = 5
+	maxCommentLength = 500
+)
+
+// htmlToText turns a comment's html into plain text
+func htmlToText(s string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(s))
+	if err != nil {
+		return ""
+	}
+	doc.Find("p").BeforeHtml("\n")
+	return strings.TrimSpace(doc.Text())
+}
+
+// countReplies counts every comment under an item
+func countReplies(item HNItem) int {
+	n := len(item.Children)
+	for _, child := range item.Children {
+		n += countReplies(child)
+	}
+	return n
+}
+
+// FetchTopComments gets the top-level comments of a story which started the most discussion,
+// since the items API doesn't give comment scores
+func FetchTopComments(object_id string) ([]string, error) {
+	bytes, err := web.Get("https://hn.algolia.com/api/v1/items/" + object_id)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching HN item: %v", err)
+	}
+	var item HNItem
+	if err := json.Unmarshal(bytes, &item); err != nil {
+		return nil, fmt.Errorf("error unmarshaling HN item: %v", err)
+	}
+
+	children := item.Children
+	sort.SliceStable(children, func(i, j int) bool {
+		return countReplies(children[i]) > countReplies(children[j])
+	})
+
+	var comments []string
+	for _, child := range children {
+		if len(comments) == maxComments {
+			break
+		}
+		text := htmlToText(child.Text)
+		if child.Type != "comment" || text == "" {
+			continue // deleted or flagged
+		}
+		if runes := []rune(text); len(runes) > maxCommentLength {
+			text = string(runes[:maxCommentLength]) + "..."
+		}
+		comments = append(comments, text)
+	}
+	return comments, nil
+}
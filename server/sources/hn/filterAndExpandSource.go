@@ -1,27 +1,40 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"strings"
-	"time"
 
 	"git.nunosempere.com/NunoSempere/news/lib/filters"
+	"git.nunosempere.com/NunoSempere/news/lib/rules"
 	"git.nunosempere.com/NunoSempere/news/lib/types"
 )
 
-func FilterAndExpandSource(source HNHit, openrouter_key string, database_url string) (types.ExpandedSource, bool) {
-	// Parse the created_at time
-	createdAt, err := time.Parse(time.RFC3339, source.CreatedAt)
-	if err != nil {
-		log.Printf("Could not parse date '%s', using current time", source.CreatedAt)
-		createdAt = time.Now()
+// Stories which gain points more slowly than this are skipped, unless they already have minPoints
+const (
+	minPointsPerHour = 1.0
+	minPoints        = 10
+)
+
+// importanceNote tells the importance check how HN has been reacting to the story
+func importanceNote(candidate *Candidate, comments []string) string {
+	points_per_hour, comments_per_hour := candidate.Velocity()
+	hn_context := fmt.Sprintf("On Hacker News: %d points (%+.1f/hour) and %d comments (%+.1f/hour), %.1f hours after being submitted.",
+		candidate.Hit.Points, points_per_hour, candidate.Hit.NumComments, comments_per_hour, candidate.Observations[len(candidate.Observations)-1].At.Sub(candidate.CreatedAt).Hours())
+	if len(comments) > 0 {
+		hn_context += "\n\nTop comments:\n- " + strings.Join(comments, "\n- ")
 	}
+	return hn_context
+}
+
+func FilterAndExpandSource(candidate *Candidate, openrouter_key string, database_url string) (types.ExpandedSource, bool) {
+	source := candidate.Hit
 
 	// Initialize expanded source
 	es := types.ExpandedSource{
 		Title:  source.Title,
 		Link:   source.URL,
-		Date:   createdAt,
+		Date:   candidate.CreatedAt,
 		Origin: "HackerNews",
 	}
 
@@ -34,6 +47,12 @@ func FilterAndExpandSource(source HNHit, openrouter_key string, database_url str
 		log.Printf("< 2 points and < 2comments")
 		return es, false
 	}
+	points_per_hour, comments_per_hour := candidate.Velocity()
+	log.Printf("%d points (%.1f/hour), %d comments (%.1f/hour)", source.Points, points_per_hour, source.NumComments, comments_per_hour)
+	if points_per_hour < minPointsPerHour && source.Points < minPoints {
+		log.Printf("Gaining points too slowly")
+		return es, false
+	}
 	if startsWithAny(source.Title, []string{"Ask HN:", "Launch HN:", "Show HN:"}) {
 		log.Printf("Ask/Launch/Show HN")
 		return es, false
@@ -63,16 +82,20 @@ func FilterAndExpandSource(source HNHit, openrouter_key string, database_url str
 		}
 	}
 
-	// Check importance
-	es, ok = filters.CheckImportance(es, openrouter_key)
+	// Check importance, with what commenters make of it
+	comments, err := FetchTopComments(source.ObjectID)
+	if err != nil {
+		log.Printf("Error fetching comments, checking importance without them: %v", err)
+	}
+	es, ok = rules.Apply(filters.ProfileImportanceFilterWithNote("", importanceNote(candidate, comments), openrouter_key)(es))
 	if !ok {
 		return es, false
 	}
 
-	// HN-specific importance boost
-	if strings.Contains(source.Title, "Saudi Arabia") {
-		es.ImportanceBool = true
-		es.ImportanceReasoning = "Contains keyword"
+	if es.ImportanceBool {
+		es, _ = filters.ExtractEventFilter(openrouter_key)(es)
+		es, _ = filters.GeotagFilter()(es)
+		es, _ = filters.TrackStoryFilter(openrouter_key, database_url)(es)
 	}
 
 	return es, es.ImportanceBool
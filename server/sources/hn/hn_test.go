@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/golden"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+// The time fixtures/web is set at, which is synthetic for now (see fixtures/README.md); the search urls depend on it
+var recordedAt = time.Date(2025, 5, 13, 18, 0, 0, 0, time.UTC)
+
+func TestMain(m *testing.M) {
+	web.SetMode(web.ModeReplay, "fixtures/web")
+	os.Exit(m.Run())
+}
+
+func TestFetchFeed(t *testing.T) {
+	hits, err := FetchFeed(recordedAt, candidateWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "hits", hits)
+}
+
+func TestFetchTopComments(t *testing.T) {
+	tests := []struct {
+		name      string
+		object_id string
+	}{
+		{"comments", "43975629"},
+		{"no_comments", "1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			comments, err := FetchTopComments(test.object_id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.Check(t, test.name, comments)
+		})
+	}
+}
@@ -28,27 +28,28 @@ func main() {
 	openrouter_key := os.Getenv("OPENROUTER_API_KEY")
 	pg_database_url := os.Getenv("DATABASE_POOL_URL")
 
-	ticker_gkg := time.NewTicker(60 * time.Minute)
-	defer ticker_gkg.Stop()
-	for ; true; <-ticker_gkg.C {
-		go func() {
+	tracker := NewTracker()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		log.Println("Polling HackerNews")
+		now := time.Now()
+		hnSources, err := FetchFeed(now, candidateWindow)
+		if err != nil {
+			log.Printf("Error fetching HackerNews feed: %v", err)
+			continue
+		}
+		tracker.Observe(hnSources, now)
+		ready := tracker.Ready(now)
+		log.Printf("Watching %d HackerNews stories, %d ready to evaluate", len(hnSources), len(ready))
 
-			log.Println("Starting HackerNews processing")
-
-			hnSources, err := FetchFeed()
-			if err != nil {
-				log.Printf("Error fetching HackerNews feed: %v", err)
-			} else {
-				log.Printf("Found %d HackerNews articles", len(hnSources))
-				for i, hit := range hnSources {
-					log.Printf("\nProcessing HackerNews article %d/%d: %s", i+1, len(hnSources), hit.Title)
-					es, ok := FilterAndExpandSource(hit, openrouter_key, pg_database_url)
-					if ok {
-						pgx.SaveSource(es)
-					}
-				}
+		for i, candidate := range ready {
+			log.Printf("\nProcessing HackerNews article %d/%d: %s", i+1, len(ready), candidate.Hit.Title)
+			es, ok := FilterAndExpandSource(candidate, openrouter_key, pg_database_url)
+			if ok {
+				pgx.SaveSource(es)
 			}
-			log.Printf("Finished processing HackerNews, sleeping for 1 hour")
-		}()
+		}
+		log.Printf("Finished processing HackerNews, polling again in %v", pollInterval)
 	}
 }
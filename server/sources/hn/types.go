@@ -9,11 +9,21 @@ type HNResponse struct {
 }
 
 type HNHit struct {
-	Title      string `json:"title"`
-	URL        string `json:"url"`
-	CreatedAt  string `json:"created_at"`
-	ObjectID   string `json:"objectID"`
-	StoryText  string `json:"story_text"` // Text content for Ask HN posts
-	Points     int    `json:"points"`
-	NumComments int   `json:"num_comments"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	CreatedAt   string `json:"created_at"`
+	ObjectID    string `json:"objectID"`
+	StoryText   string `json:"story_text"` // Text content for Ask HN posts
+	Points      int    `json:"points"`
+	NumComments int    `json:"num_comments"`
+}
+
+// HNItem is a story or comment from the Algolia items API, with its replies
+type HNItem struct {
+	ID        int      `json:"id"`
+	Type      string   `json:"type"` // "story" or "comment"
+	Author    string   `json:"author"`
+	Text      string   `json:"text"` // html
+	CreatedAt string   `json:"created_at"`
+	Children  []HNItem `json:"children"`
 }
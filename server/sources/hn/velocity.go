@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+/* Points alone say little about a story which is an hour old. So we poll the stories of the last few hours
+repeatedly, and judge each one by how fast it gains points and comments, once we have seen it for a while. */
+
+const (
+	pollInterval    = 20 * time.Minute
+	candidateWindow = 4 * time.Hour
+	// A story is evaluated once it is minAge old and we have watched it for minWatch,
+	// or at the latest when it is maxAge old, e.g. for stories we first saw after a restart
+	minAge   = 1 * time.Hour
+	minWatch = 40 * time.Minute
+	maxAge   = 3 * time.Hour
+)
+
+type Observation struct {
+	At       time.Time
+	Points   int
+	Comments int
+}
+
+type Candidate struct {
+	Hit          HNHit // as last seen
+	CreatedAt    time.Time
+	Observations []Observation
+	Evaluated    bool
+}
+
+// Tracker remembers the candidate stories between polls
+type Tracker struct {
+	candidates map[string]*Candidate
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{candidates: map[string]*Candidate{}}
+}
+
+// Observe records the current points and comments of the stories in a poll
+func (t *Tracker) Observe(hits []HNHit, now time.Time) {
+	for _, hit := range hits {
+		candidate, ok := t.candidates[hit.ObjectID]
+		if !ok {
+			created_at, err := time.Parse(time.RFC3339, hit.CreatedAt)
+			if err != nil {
+				log.Printf("Could not parse date '%s', using current time", hit.CreatedAt)
+				created_at = now
+			}
+			candidate = &Candidate{CreatedAt: created_at}
+			t.candidates[hit.ObjectID] = candidate
+		}
+		candidate.Hit = hit
+		candidate.Observations = append(candidate.Observations, Observation{At: now, Points: hit.Points, Comments: hit.NumComments})
+	}
+}
+
+// Ready returns the stories due for evaluation, which won't be returned again, and forgets old ones
+func (t *Tracker) Ready(now time.Time) []*Candidate {
+	var ready []*Candidate
+	for id, candidate := range t.candidates {
+		age := now.Sub(candidate.CreatedAt)
+		if age > candidateWindow+pollInterval {
+			delete(t.candidates, id)
+			continue
+		}
+		watched := now.Sub(candidate.Observations[0].At)
+		if !candidate.Evaluated && (age >= minAge && watched >= minWatch || age >= maxAge) {
+			candidate.Evaluated = true
+			ready = append(ready, candidate)
+		}
+	}
+	return ready
+}
+
+// Velocity is points and comments per hour while we watched the story,
+// or since it was submitted if we have only seen it once
+func (c *Candidate) Velocity() (float64, float64) {
+	first, last := c.Observations[0], c.Observations[len(c.Observations)-1]
+	hours := last.At.Sub(first.At).Hours()
+	if len(c.Observations) < 2 || hours < 0.1 {
+		hours = max(last.At.Sub(c.CreatedAt).Hours(), 0.1)
+		return float64(last.Points) / hours, float64(last.Comments) / hours
+	}
+	return float64(last.Points-first.Points) / hours, float64(last.Comments-first.Comments) / hours
+}
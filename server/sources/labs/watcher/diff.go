@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+)
+
+// Longest diff we send to the LLM
+const maxDiffLength = 8000
+
+// Diff is what changed between two versions of a page, paragraph by paragraph, in page order.
+// Paragraphs which only moved are not counted.
+type Diff struct {
+	Added   []string
+	Removed []string
+}
+
+func (d Diff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+func paragraphs(content string) []string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// only returns the paragraphs of a which aren't in b, counting repeats
+func only(a []string, b []string) []string {
+	counts := map[string]int{}
+	for _, line := range b {
+		counts[line]++
+	}
+	var result []string
+	for _, line := range a {
+		if counts[line] > 0 {
+			counts[line]--
+			continue
+		}
+		result = append(result, line)
+	}
+	return result
+}
+
+func DiffParagraphs(old_content string, new_content string) Diff {
+	old_paragraphs, new_paragraphs := paragraphs(old_content), paragraphs(new_content)
+	return Diff{
+		Added:   only(new_paragraphs, old_paragraphs),
+		Removed: only(old_paragraphs, new_paragraphs),
+	}
+}
+
+// String shows the diff like a unified diff without context, cut to maxDiffLength
+func (d Diff) String() string {
+	var b strings.Builder
+	for _, line := range d.Removed {
+		b.WriteString("- " + line + "\n")
+	}
+	for _, line := range d.Added {
+		b.WriteString("+ " + line + "\n")
+	}
+	s := b.String()
+	if runes := []rune(s); len(runes) > maxDiffLength {
+		s = string(runes[:maxDiffLength]) + "\n[...]"
+	}
+	return s
+}
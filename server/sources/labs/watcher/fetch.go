@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"git.nunosempere.com/NunoSempere/news/lib/config"
+	"git.nunosempere.com/NunoSempere/news/lib/extractors"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+const pagesFile = "pages.json"
+
+// Page kinds, which say what a change to the page is likely to mean
+var page_kinds = []string{"models", "model-card", "system-card", "safety-policy", "usage-policy", "evals"}
+
+// Page is one entry in pages.json
+type Page struct {
+	Lab      string `json:"lab"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	URL      string `json:"url"`
+	Selector string `json:"selector,omitempty"` // css selector for the part of the page to watch; the main content if empty
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// We check the same few pages on every run, so there is no hurry
+var watchClient = web.NewClient(web.Config{RequestsPerSec: 0.2, Burst: 1, RespectRobots: true})
+
+// LoadPages reads the lab pages to watch from pages.json
+func LoadPages() ([]Page, error) {
+	return config.LoadList(pagesFile, "page", validatePage, func(page Page) bool { return page.Disabled })
+}
+
+func validatePage(page *Page) ([]string, error) {
+	if !config.IsURL(page.URL) {
+		return nil, fmt.Errorf("%q is not a url", page.URL)
+	}
+	if page.Lab == "" || page.Name == "" {
+		return nil, fmt.Errorf("%s needs a lab and a name", page.URL)
+	}
+	if !slices.Contains(page_kinds, page.Kind) {
+		return nil, fmt.Errorf("%s has an unknown kind %q", page.URL, page.Kind)
+	}
+	return []string{page.URL}, nil
+}
+
+// FetchPageText gets the text of the watched part of a page, one paragraph per line
+func FetchPageText(page Page) (string, error) {
+	html, err := watchClient.Get(page.URL)
+	if err != nil {
+		return "", err
+	}
+	rule := extractors.Rule{
+		Name:   page.Lab + " " + page.Name,
+		Body:   page.Selector,
+		Remove: []string{"nav", "header", "footer", "aside", "form"},
+	}
+	if rule.Body == "" {
+		rule.Body = "main, article, body"
+	}
+	extracted, err := extractors.Extract(html, rule)
+	if err != nil {
+		return "", err
+	}
+	return extracted.Body, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/filters"
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+var ErrMinorEdit = errors.New("minor edit")
+
+// Tag for changes the LLM put in a category we don't know
+const otherChangeCategory = "page change"
+
+func slug(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), "-"))
+}
+
+// DescribeChange turns a change to a page into a source, tagged with the lab and the kind of change.
+// Each change gets its own link, since links are unique in the sources table.
+func DescribeChange(page Page, diff Diff, now time.Time, openrouter_key string) (types.ExpandedSource, error) {
+	change, err := llm.DescribePageChange(page.Lab, page.Name, diff.String(), openrouter_key)
+	if err != nil {
+		return types.ExpandedSource{}, err
+	}
+	if change == nil {
+		return types.ExpandedSource{}, errors.New("no description of the change")
+	}
+	if !slices.Contains(llm.PageChangeCategories, change.Category) {
+		log.Printf("Unknown change category %q, using %q", change.Category, otherChangeCategory)
+		change.Category = otherChangeCategory
+	}
+	log.Printf("Change (%s): %s", change.Category, change.Title)
+	if change.Category == "minor edit" {
+		return types.ExpandedSource{}, ErrMinorEdit
+	}
+	return types.ExpandedSource{
+		Title:   change.Title,
+		Link:    page.URL + "#changed-" + now.UTC().Format("2006-01-02T15:04"),
+		Date:    now,
+		Summary: change.Summary,
+		Origin:  page.Lab + " (" + page.Name + ")",
+		Tags:    []string{slug(page.Lab), slug(change.Category), page.Kind},
+	}, nil
+}
+
+// FilterAndExpandSource checks the importance of a change with the AI labs profile
+func FilterAndExpandSource(es types.ExpandedSource, openrouter_key string, database_url string) (types.ExpandedSource, bool) {
+	fs := []types.Filter{
+		filters.IsGoodHostFilter(),
+		filters.WithRules(filters.ProfileImportanceFilter("ai-labs", openrouter_key)),
+		filters.ExtractEventFilter(openrouter_key),
+		filters.GeotagFilter(),
+		filters.TrackStoryFilter(openrouter_key, database_url),
+	}
+	return filters.ApplyFilters(es, fs)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/pgx"
+	"github.com/joho/godotenv"
+)
+
+// checkPage diffs a page against its last snapshot, and saves a source describing the change if there is one
+func checkPage(page Page, openrouter_key string, database_url string) {
+	content, err := FetchPageText(page)
+	if err != nil {
+		log.Printf("Error fetching page: %v", err)
+		return
+	}
+	last, has_last, err := getLastSnapshot(database_url, page.URL)
+	if err != nil {
+		return
+	}
+	if !has_last {
+		if err := saveSnapshot(database_url, page.URL, content); err == nil {
+			log.Printf("Saved first snapshot")
+		}
+		return
+	}
+	if hashContent(content) == last.Hash {
+		log.Printf("Unchanged since %s", last.FetchedAt.Format(time.DateTime))
+		return
+	}
+
+	diff := DiffParagraphs(last.Content, content)
+	if diff.IsEmpty() {
+		log.Printf("Only reordered or reformatted")
+		saveSnapshot(database_url, page.URL, content)
+		return
+	}
+	log.Printf("%d paragraphs added and %d removed", len(diff.Added), len(diff.Removed))
+
+	es, err := DescribeChange(page, diff, time.Now(), openrouter_key)
+	if errors.Is(err, ErrMinorEdit) {
+		saveSnapshot(database_url, page.URL, content)
+		return
+	} else if err != nil {
+		// Keep the old snapshot, so that the change is described on the next run
+		log.Printf("Error describing change: %v", err)
+		return
+	}
+	es, ok := FilterAndExpandSource(es, openrouter_key, database_url)
+	if !ok && es.Failed {
+		// As above; the importance check didn't get to judge the change
+		log.Printf("Will describe the change again on the next run")
+		return
+	}
+	// Without the new snapshot, the next run would describe and save the same change again
+	if err := saveSnapshot(database_url, page.URL, content); err != nil {
+		log.Printf("Not saving the change, since its snapshot couldn't be saved")
+		return
+	}
+	// Always save to AI database, and save to main database if passes filters
+	if ok {
+		pgx.SaveToMainDatabase(es)
+	}
+	pgx.SaveToAIDatabase(es)
+}
+
+func main() {
+	// Set up logging
+	logFile, err := os.OpenFile("v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Load environment variables
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openrouter_key := os.Getenv("OPENROUTER_API_KEY")
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	pages, err := LoadPages()
+	if err != nil {
+		log.Fatalf("Error loading pages: %v", err)
+	}
+
+	for {
+		for i, page := range pages {
+			log.Printf("\nChecking page %d/%d: %s %s (%s)", i+1, len(pages), page.Lab, page.Name, page.URL)
+			checkPage(page, openrouter_key, pg_database_url)
+		}
+
+		log.Printf("Finished checking lab pages, sleeping for 6 hours")
+		time.Sleep(6 * time.Hour)
+	}
+}
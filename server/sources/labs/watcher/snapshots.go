@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Snapshot struct {
+	Content   string
+	Hash      string
+	FetchedAt time.Time
+}
+
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// getLastSnapshot returns the latest snapshot of a page, and false if there is none yet
+func getLastSnapshot(database_url string, page_url string) (Snapshot, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return Snapshot{}, false, err
+	}
+	defer conn.Close(context.Background())
+
+	var snapshot Snapshot
+	err = conn.QueryRow(ctx, `
+		SELECT content, content_hash, fetched_at FROM page_snapshots
+		WHERE url = $1
+		ORDER BY fetched_at DESC
+		LIMIT 1
+	`, page_url).Scan(&snapshot.Content, &snapshot.Hash, &snapshot.FetchedAt)
+	if err == pgx.ErrNoRows {
+		return Snapshot{}, false, nil
+	} else if err != nil {
+		log.Printf("Error getting page snapshot: %v\n", err)
+		return Snapshot{}, false, err
+	}
+	return snapshot, true, nil
+}
+
+// saveSnapshot records a new version of a page, logging any error before returning it
+func saveSnapshot(database_url string, page_url string, content string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, `
+		INSERT INTO page_snapshots (url, content_hash, content)
+		VALUES ($1, $2, $3)
+	`, page_url, hashContent(content), content)
+	if err != nil {
+		log.Printf("Error saving page snapshot: %v\n", err)
+		return err
+	}
+	return nil
+}
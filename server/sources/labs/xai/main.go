@@ -1,59 +0,0 @@
-package main
-
-import (
-	"io"
-	"log"
-	"os"
-	"time"
-
-	"git.nunosempere.com/NunoSempere/news/lib/pgx"
-	"github.com/joho/godotenv"
-)
-
-func main() {
-	// Set up logging
-	logFile, err := os.OpenFile("v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalf("error opening file: %v", err)
-	}
-	defer logFile.Close()
-	mw := io.MultiWriter(os.Stdout, logFile)
-	log.SetOutput(mw)
-
-	// Load environment variables
-	err = godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
-	openrouter_key := os.Getenv("OPENROUTER_API_KEY")
-	pg_database_url := os.Getenv("DATABASE_POOL_URL")
-
-	for {
-		log.Println("Starting xAI tweets processing")
-
-		sources, err := FetchSources()
-		if err != nil {
-			log.Printf("Error fetching sources: %v", err)
-			continue
-		}
-
-		log.Printf("Found %d weekly tweet articles", len(sources))
-
-		// Process each weekly article
-		for i, source := range sources {
-			articleContent := source.Title // The full article content is here
-			properTitle := extractTitleFromContent(articleContent)
-			log.Printf("\nProcessing article %d/%d: %s", i+1, len(sources), properTitle)
-
-			es, ok := FilterAndExpandSource(source, openrouter_key, pg_database_url)
-			// Always save to AI database, and save to main database if passes filters
-			if ok {
-				pgx.SaveToMainDatabase(es)
-			}
-			pgx.SaveToAIDatabase(es)
-		}
-
-		log.Printf("Finished processing xAI tweets, sleeping for 1 week")
-		time.Sleep(7 * 24 * time.Hour) // Sleep for 1 week
-	}
-}
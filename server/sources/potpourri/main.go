@@ -40,7 +40,7 @@ func main() {
 			switch sourceCfg.Name {
 			case "DSCA":
 				log.Println("Processing DSCA feed")
-				dscaSources, err := dsca.FetchFeed()
+				dscaSources, err := dsca.FetchFeed(time.Now())
 				if err != nil {
 					log.Printf("Error fetching DSCA feed: %v", err)
 				} else {
@@ -57,7 +57,7 @@ func main() {
 
 			case "WhiteHouse":
 				log.Println("Processing White House feed")
-				whSources, err := whitehouse.FetchFeed()
+				whSources, err := whitehouse.FetchFeed(time.Now())
 				if err != nil {
 					log.Printf("Error fetching White House feed: %v", err)
 				} else {
@@ -74,7 +74,7 @@ func main() {
 
 			case "CNN":
 				log.Println("Processing CNN feeds")
-				cnnSources, err := cnn.FetchAllFeeds()
+				cnnSources, err := cnn.FetchAllFeeds(time.Now())
 				if err != nil {
 					log.Printf("Error fetching CNN feeds: %v", err)
 				} else {
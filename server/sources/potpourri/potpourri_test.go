@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/golden"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"git.nunosempere.com/NunoSempere/news/sources/potpourri/cnn"
+	"git.nunosempere.com/NunoSempere/news/sources/potpourri/dsca"
+	"git.nunosempere.com/NunoSempere/news/sources/potpourri/whitehouse"
+)
+
+// The time fixtures/web is set at, which is synthetic for now (see fixtures/README.md); feeds only keep the last day of articles
+var recordedAt = time.Date(2025, 5, 13, 21, 0, 0, 0, time.UTC)
+
+func TestMain(m *testing.M) {
+	web.SetMode(web.ModeReplay, "fixtures/web")
+	os.Exit(m.Run())
+}
+
+func TestFetchFeeds(t *testing.T) {
+	tests := []struct {
+		name  string
+		fetch func(now time.Time) ([]types.Source, error)
+	}{
+		{"cnn_world", func(now time.Time) ([]types.Source, error) {
+			return cnn.FetchFeed("world", "http://rss.cnn.com/rss/cnn_world.rss", now)
+		}},
+		{"cnn_technology", func(now time.Time) ([]types.Source, error) {
+			return cnn.FetchFeed("technology", "http://rss.cnn.com/rss/cnn_tech.rss", now)
+		}},
+		{"dsca", dsca.FetchFeed},
+		{"whitehouse", whitehouse.FetchFeed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sources, err := test.fetch(recordedAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.Check(t, test.name, sources)
+		})
+	}
+}
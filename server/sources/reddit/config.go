@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"git.nunosempere.com/NunoSempere/news/lib/config"
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+)
+
+const subredditsFile = "subreddits.json"
+
+var subreddit_name = regexp.MustCompile(`^[A-Za-z0-9_]{2,21}$`)
+
+// Group is one entry in subreddits.json: subreddits which are fetched together and share a region
+type Group struct {
+	Name              string   `json:"name"`
+	Region            string   `json:"region,omitempty"`             // where the subreddits' posters are, e.g. "Texas"; empty for nowhere in particular
+	Subreddits        []string `json:"subreddits"`                   // without the r/
+	ImportanceProfile string   `json:"importance_profile,omitempty"` // for spikes in one of these subreddits; see lib/llm/profiles.json
+	Disabled          bool     `json:"disabled,omitempty"`
+}
+
+// LoadGroups reads the groups of subreddits to watch from subreddits.json. A subreddit can only be in one group,
+// since its spikes are judged with its group's region and profile.
+func LoadGroups() ([]Group, error) {
+	return config.LoadList(subredditsFile, "group", validateGroup, func(group Group) bool { return group.Disabled })
+}
+
+func validateGroup(group *Group) ([]string, error) {
+	if group.Name == "" || len(group.Subreddits) == 0 {
+		return nil, fmt.Errorf("needs a name and subreddits")
+	}
+	if _, ok := llm.GetProfile(group.ImportanceProfile); group.ImportanceProfile != "" && !ok {
+		return nil, fmt.Errorf("%s has an unknown importance_profile %q", group.Name, group.ImportanceProfile)
+	}
+	var keys []string
+	for i, subreddit := range group.Subreddits {
+		subreddit = strings.TrimPrefix(strings.TrimPrefix(subreddit, "/"), "r/")
+		if !subreddit_name.MatchString(subreddit) {
+			return nil, fmt.Errorf("%s has an invalid subreddit %q", group.Name, group.Subreddits[i])
+		}
+		group.Subreddits[i] = subreddit
+		keys = append(keys, "r/"+strings.ToLower(subreddit))
+	}
+	return keys, nil
+}
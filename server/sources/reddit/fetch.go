@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+// Subreddits fetched with one request, as a multireddit like r/a+b+c. Listings have at most 100 posts,
+// so busy subreddits should go in small groups, or some of their posts will be missed between polls.
+const multiredditSize = 25
+
+// Reddit allows about ten unauthenticated requests a minute, and asks for a descriptive user agent
+var redditClient = web.NewClient(web.Config{
+	RequestsPerSec: 0.1,
+	Burst:          2,
+	UserAgent:      "server:git.nunosempere.com/NunoSempere/news:v1 (news monitoring)",
+})
+
+type Listing struct {
+	Data struct {
+		Children []struct {
+			Data Post `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+type Post struct {
+	ID         string    `json:"id"`
+	Subreddit  string    `json:"subreddit"`
+	Title      string    `json:"title"`
+	Permalink  string    `json:"permalink"`
+	CreatedUTC float64   `json:"created_utc"`
+	Stickied   bool      `json:"stickied"`
+	Region     string    `json:"-"` // of the post's group
+	Created    time.Time `json:"-"` // CreatedUTC, parsed
+	Alarm      int       `json:"-"` // see llm.TriageTitles
+	Category   string    `json:"-"`
+}
+
+func (post Post) URL() string {
+	return "https://www.reddit.com" + post.Permalink
+}
+
+// fetchListing gets the newest posts of a subreddit or multireddit
+func fetchListing(subreddits []string) ([]Post, error) {
+	url := fmt.Sprintf("https://www.reddit.com/r/%s/new.json?limit=100&raw_json=1", strings.Join(subreddits, "+"))
+	resp, err := redditClient.Open(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var listing Listing
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, err
+	}
+	var posts []Post
+	for _, child := range listing.Data.Children {
+		post := child.Data
+		if post.Stickied || post.ID == "" {
+			continue
+		}
+		post.Created = time.Unix(int64(post.CreatedUTC), 0).UTC()
+		post.Category = "none"
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
+
+// FetchGroup gets the newest posts of a group's subreddits, a multireddit at a time
+func FetchGroup(group Group) []Post {
+	var posts []Post
+	for i := 0; i < len(group.Subreddits); i += multiredditSize {
+		chunk := group.Subreddits[i:min(i+multiredditSize, len(group.Subreddits))]
+		chunk_posts, err := fetchListing(chunk)
+		if err != nil {
+			log.Printf("Error fetching r/%s: %v", strings.Join(chunk, "+"), err)
+			continue
+		}
+		for _, post := range chunk_posts {
+			post.Region = group.Region
+			posts = append(posts, post)
+		}
+	}
+	return posts
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/filters"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// Importance profiles for spikes of alarming posts; other categories have theirs detected
+var category_profiles = map[string]string{
+	"illness or outbreak":        "biosecurity",
+	"AI psychosis or parasitism": "ai-labs",
+	"AI incident":                "ai-labs",
+}
+
+func subredditList(posts []Post) string {
+	var subreddits []string
+	seen := map[string]bool{}
+	for _, post := range posts {
+		if !seen[post.Subreddit] {
+			seen[post.Subreddit] = true
+			subreddits = append(subreddits, "r/"+post.Subreddit)
+		}
+	}
+	if len(subreddits) > 3 {
+		return strings.Join(subreddits[:3], ", ") + " and others"
+	}
+	return strings.Join(subreddits, ", ")
+}
+
+// SpikeSource makes an item out of a spike, listing its posts, linked to its most alarming post
+func SpikeSource(spike Spike, now time.Time) types.Source {
+	counts := fmt.Sprintf("%d posts in the last %.0f hours, against about %.1f usually", spike.Recent, spikeWindow.Hours(), spike.Expected)
+	title := fmt.Sprintf("Surge of posts on r/%s: %s", spike.Subreddit, counts)
+	if spike.IsTopic() {
+		where := "on " + subredditList(spike.Posts)
+		if spike.Region != "" {
+			where = "from " + spike.Region + " (" + subredditList(spike.Posts) + ")"
+		}
+		title = fmt.Sprintf("Surge of reddit posts about %s %s: %s", spike.Category, where, counts)
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "# %s\n\n", title)
+	for _, post := range spike.Posts {
+		fmt.Fprintf(&content, "- r/%s, %s: [%s](%s)", post.Subreddit, post.Created.Format("Jan 2 15:04 UTC"), post.Title, post.URL())
+		if post.Category != "none" {
+			fmt.Fprintf(&content, " (%s, alarm %d/10)", post.Category, post.Alarm)
+		}
+		content.WriteString("\n")
+	}
+	if len(spike.Posts) < spike.Recent {
+		fmt.Fprintf(&content, "\nAnd %d more posts.\n", spike.Recent-len(spike.Posts))
+	}
+
+	return types.Source{
+		Title:   title,
+		Link:    spike.Posts[0].URL(),
+		Date:    now,
+		Origin:  "reddit",
+		Content: content.String(),
+	}
+}
+
+// FilterAndExpandSource checks a spike's importance, with the profile of its category or subreddit
+func FilterAndExpandSource(source types.Source, importance_profile string, openrouter_key string, database_url string) (types.ExpandedSource, bool) {
+	es := types.ExpandedSource{
+		Title:   source.Title,
+		Link:    source.Link,
+		Date:    source.Date,
+		Origin:  source.Origin,
+		Content: source.Content,
+	}
+
+	fs := []types.Filter{
+		filters.IsDupeFilter(database_url),
+		filters.ExtractSummaryFilter(openrouter_key),
+		filters.WithRules(filters.ProfileImportanceFilter(importance_profile, openrouter_key)),
+		filters.ExtractEventFilter(openrouter_key),
+		filters.GeotagFilter(),
+		filters.TrackStoryFilter(openrouter_key, database_url),
+	}
+	return filters.ApplyFilters(es, fs)
+}
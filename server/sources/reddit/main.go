@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/pgx"
+	"github.com/joho/godotenv"
+)
+
+const pollInterval = 15 * time.Minute
+
+func main() {
+	// Set up logging
+	logFile, err := os.OpenFile("v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Load environment variables
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openrouter_key := os.Getenv("OPENROUTER_API_KEY")
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	groups, err := LoadGroups()
+	if err != nil {
+		log.Fatalf("Error loading subreddits: %v", err)
+	}
+	subreddit_groups := map[string]Group{}
+	for _, group := range groups {
+		for _, subreddit := range group.Subreddits {
+			subreddit_groups[strings.ToLower(subreddit)] = group
+		}
+	}
+
+	for {
+		now := time.Now()
+		for _, group := range groups {
+			posts, err := newPosts(pg_database_url, FetchGroup(group))
+			if err != nil {
+				continue
+			}
+			triaged := TriagePosts(posts, openrouter_key)
+			log.Printf("Group %s: %d new posts, %d triaged", group.Name, len(posts), len(triaged))
+			savePosts(pg_database_url, triaged)
+		}
+
+		spikes, err := FindSpikes(pg_database_url, now)
+		if err != nil {
+			log.Printf("Error finding spikes: %v", err)
+		}
+		for _, spike := range spikes {
+			source := SpikeSource(spike, now)
+			log.Printf("\nProcessing: %s", source.Title)
+
+			importance_profile := category_profiles[spike.Category]
+			if !spike.IsTopic() {
+				importance_profile = subreddit_groups[strings.ToLower(spike.Subreddit)].ImportanceProfile
+			}
+			es, ok := FilterAndExpandSource(source, importance_profile, openrouter_key, pg_database_url)
+			if !ok && es.Failed {
+				log.Printf("Will try again on the next poll")
+				continue
+			}
+			MarkSpiked(pg_database_url, spike, now)
+			if ok {
+				pgx.SaveSource(es)
+			}
+		}
+		prunePosts(pg_database_url, now)
+
+		log.Printf("Finished processing subreddits, sleeping for %v", pollInterval)
+		time.Sleep(pollInterval)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// newPosts returns the posts which aren't in reddit_posts yet
+func newPosts(database_url string, posts []Post) ([]Post, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return nil, err
+	}
+	defer conn.Close(context.Background())
+
+	ids := make([]string, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	rows, err := conn.Query(ctx, `SELECT id FROM reddit_posts WHERE id = ANY($1)`, ids)
+	if err != nil {
+		log.Printf("Error checking reddit posts: %v\n", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	is_known := map[string]bool{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		is_known[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var result []Post
+	for _, post := range posts {
+		if !is_known[post.ID] {
+			result = append(result, post)
+			is_known[post.ID] = true // listings of different groups shouldn't overlap, but just in case
+		}
+	}
+	return result, nil
+}
+
+// savePosts records triaged posts. Times are all in UTC, as reddit gives them.
+func savePosts(database_url string, posts []Post) error {
+	seen_at := time.Now().UTC()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	for _, post := range posts {
+		_, err = conn.Exec(ctx, `
+			INSERT INTO reddit_posts (id, subreddit, region, title, permalink, created_at, alarm, category, seen_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (id) DO NOTHING
+		`, post.ID, post.Subreddit, post.Region, post.Title, post.Permalink, post.Created, post.Alarm, post.Category, seen_at)
+		if err != nil {
+			log.Printf("Error saving reddit post: %v\n", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// prunePosts forgets posts which are too old to be part of a baseline
+func prunePosts(database_url string, now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, `DELETE FROM reddit_posts WHERE created_at < $1`, now.Add(-2*baselineWindow))
+	if err != nil {
+		log.Printf("Error pruning reddit posts: %v\n", err)
+	}
+}
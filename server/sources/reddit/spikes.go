@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+/* A spike is a surge of posts against a baseline of the same kind of posts in the preceding week:
+either alarming posts of one category from one region, like posts about a mystery illness from
+Indian subreddits, or any posts in one subreddit. Posts are only counted in one spike. */
+
+const (
+	spikeWindow       = 6 * time.Hour      // posts this recent are counted against the baseline
+	baselineWindow    = 7 * 24 * time.Hour // before the spike window
+	minBaseline       = 24 * time.Hour     // of watching, before anything counts as a spike
+	spikeFactor       = 4.0                // times the posts expected from the baseline
+	minAlarm          = 6                  // posts with a lower alarm don't count towards a category's spikes
+	minTopicPosts     = 3
+	minSubredditPosts = 10
+	maxSpikePosts     = 30 // listed in a spike's item
+)
+
+type Spike struct {
+	Subreddit string // for a spike of a subreddit's posts
+	Region    string // for a spike of alarming posts; may be empty
+	Category  string // likewise
+	Recent    int    // posts in the spike window, not counting those of another spike
+	Expected  float64
+	Posts     []Post   // the most alarming first, up to maxSpikePosts
+	PostIDs   []string // of all its posts, to mark once the spike has been judged
+}
+
+func (spike Spike) IsTopic() bool {
+	return spike.Category != ""
+}
+
+// expectedPosts scales the posts in the baseline to the spike window. It returns false until
+// the baseline is long enough to go by.
+func expectedPosts(baseline_count int, watched_since time.Time, now time.Time) (float64, bool) {
+	baseline := now.Add(-spikeWindow).Sub(watched_since)
+	if baseline < minBaseline {
+		return 0, false
+	}
+	return float64(baseline_count) * spikeWindow.Hours() / baseline.Hours(), true
+}
+
+func isSpike(recent int, expected float64, min_posts int) bool {
+	return recent >= min_posts && float64(recent) >= spikeFactor*expected
+}
+
+// FindSpikes finds the spikes of alarming posts, and then those of subreddits. Their posts are only marked as spiked
+// once a spike has been judged (see MarkSpiked), so that a spike whose judgement failed is found again on the next poll.
+func FindSpikes(database_url string, now time.Time) ([]Spike, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return nil, err
+	}
+	defer conn.Close(context.Background())
+
+	now = now.UTC()
+	since, baseline_from := now.Add(-spikeWindow), now.Add(-baselineWindow)
+	var spikes []Spike
+
+	// Alarming posts are rare, so a category is watched for as long as any posts are
+	rows, err := conn.Query(ctx, `
+		WITH watched AS (SELECT GREATEST(MIN(seen_at), $2) AS since FROM reddit_posts)
+		SELECT p.region, p.category, w.since,
+			COUNT(*) FILTER (WHERE p.created_at > $1 AND p.spiked_at IS NULL),
+			COUNT(*) FILTER (WHERE p.created_at > w.since AND p.created_at <= $1)
+		FROM reddit_posts p, watched w
+		WHERE p.category <> 'none' AND p.alarm >= $3 AND p.created_at > LEAST(w.since, $1)
+		GROUP BY p.region, p.category, w.since
+	`, since, baseline_from, minAlarm)
+	if err != nil {
+		log.Printf("Error counting alarming reddit posts: %v\n", err)
+		return nil, err
+	}
+	for rows.Next() {
+		var spike Spike
+		var watched_since time.Time
+		var baseline_count int
+		if err := rows.Scan(&spike.Region, &spike.Category, &watched_since, &spike.Recent, &baseline_count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		var ok bool
+		if spike.Expected, ok = expectedPosts(baseline_count, watched_since, now); ok && isSpike(spike.Recent, spike.Expected, minTopicPosts) {
+			spikes = append(spikes, spike)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = conn.Query(ctx, `
+		WITH watched AS (SELECT subreddit, GREATEST(MIN(seen_at), $2) AS since FROM reddit_posts GROUP BY subreddit)
+		SELECT p.subreddit, w.since,
+			COUNT(*) FILTER (WHERE p.created_at > $1 AND p.spiked_at IS NULL),
+			COUNT(*) FILTER (WHERE p.created_at > w.since AND p.created_at <= $1)
+		FROM reddit_posts p JOIN watched w USING (subreddit)
+		WHERE p.created_at > LEAST(w.since, $1)
+		GROUP BY p.subreddit, w.since
+	`, since, baseline_from)
+	if err != nil {
+		log.Printf("Error counting reddit posts: %v\n", err)
+		return nil, err
+	}
+	var subreddit_spikes []Spike
+	for rows.Next() {
+		var spike Spike
+		var watched_since time.Time
+		var baseline_count int
+		if err := rows.Scan(&spike.Subreddit, &watched_since, &spike.Recent, &baseline_count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		var ok bool
+		if spike.Expected, ok = expectedPosts(baseline_count, watched_since, now); ok && isSpike(spike.Recent, spike.Expected, minSubredditPosts) {
+			subreddit_spikes = append(subreddit_spikes, spike)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Posts of the alarming spikes are taken first, so that they aren't listed again in a subreddit's spike
+	taken := map[string]bool{}
+	var result []Spike
+	for _, spike := range append(spikes, subreddit_spikes...) {
+		posts, err := spikePosts(ctx, conn, spike, since)
+		if err != nil {
+			log.Printf("Error getting the posts of a reddit spike: %v\n", err)
+			continue
+		}
+		var own_posts []Post
+		for _, post := range posts {
+			if !taken[post.ID] {
+				own_posts = append(own_posts, post)
+			}
+		}
+		min_posts := minSubredditPosts
+		if spike.IsTopic() {
+			min_posts = minTopicPosts
+		}
+		spike.Recent = len(own_posts)
+		if !isSpike(spike.Recent, spike.Expected, min_posts) {
+			continue
+		}
+		for _, post := range own_posts {
+			taken[post.ID] = true
+			spike.PostIDs = append(spike.PostIDs, post.ID)
+		}
+		spike.Posts = mostAlarming(own_posts)
+		result = append(result, spike)
+	}
+	return result, nil
+}
+
+// spikePosts gets the posts of a spike which aren't part of an earlier spike
+func spikePosts(ctx context.Context, conn *pgx.Conn, spike Spike, since time.Time) ([]Post, error) {
+	condition, args := `subreddit = $2`, []any{since, spike.Subreddit}
+	if spike.IsTopic() {
+		condition, args = `region = $2 AND category = $3 AND alarm >= $4`, []any{since, spike.Region, spike.Category, minAlarm}
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT id, subreddit, region, title, permalink, created_at, alarm, category
+		FROM reddit_posts
+		WHERE created_at > $1 AND spiked_at IS NULL AND `+condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []Post
+	for rows.Next() {
+		var post Post
+		if err := rows.Scan(&post.ID, &post.Subreddit, &post.Region, &post.Title, &post.Permalink, &post.Created, &post.Alarm, &post.Category); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+// mostAlarming sorts posts by alarm, then the newest first, and keeps the first maxSpikePosts
+func mostAlarming(posts []Post) []Post {
+	sort.SliceStable(posts, func(i, j int) bool {
+		if posts[i].Alarm != posts[j].Alarm {
+			return posts[i].Alarm > posts[j].Alarm
+		}
+		return posts[i].Created.After(posts[j].Created)
+	})
+	if len(posts) > maxSpikePosts {
+		posts = posts[:maxSpikePosts]
+	}
+	return posts
+}
+
+// MarkSpiked marks the posts of a judged spike, so that they aren't counted in another one
+func MarkSpiked(database_url string, spike Spike, now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, `UPDATE reddit_posts SET spiked_at = $1 WHERE id = ANY($2)`, now, spike.PostIDs)
+	if err != nil {
+		log.Printf("Error marking reddit posts as spiked: %v\n", err)
+		return err
+	}
+	return nil
+}
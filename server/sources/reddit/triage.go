@@ -0,0 +1,46 @@
+package main
+
+import (
+	"log"
+	"slices"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+)
+
+// Titles sent to the LLM at once
+const triageBatchSize = 50
+
+// TriagePosts scores new posts' titles, a batch at a time, and returns the posts of the batches which could be triaged.
+// Posts of failed batches are left out, so that they are tried again on the next poll.
+func TriagePosts(posts []Post, openrouter_key string) []Post {
+	var triaged []Post
+	for start := 0; start < len(posts); start += triageBatchSize {
+		batch := slices.Clone(posts[start:min(start+triageBatchSize, len(posts))])
+		titles := make([]string, len(batch))
+		for i, post := range batch {
+			titles[i] = "r/" + post.Subreddit + ": " + post.Title
+		}
+
+		triage_box, err := llm.TriageTitles(titles, openrouter_key)
+		if err != nil || triage_box == nil {
+			log.Printf("Error triaging titles: %v", err)
+			continue
+		}
+		for _, item := range triage_box.Items {
+			if item.Index < 1 || item.Index > len(batch) {
+				log.Printf("Triage returned an unknown index %d", item.Index)
+				continue
+			}
+			post := &batch[item.Index-1]
+			post.Alarm = max(0, min(item.Alarm, 10))
+			if slices.Contains(llm.AlarmCategories, item.Category) {
+				post.Category = item.Category
+			}
+			if post.Alarm >= minAlarm {
+				log.Printf("Alarming (%d, %s): r/%s: %s", post.Alarm, post.Category, post.Subreddit, post.Title)
+			}
+		}
+		triaged = append(triaged, batch...)
+	}
+	return triaged
+}
@@ -2,35 +2,17 @@ package main
 
 import (
 	"encoding/json"
-	"encoding/xml"
-	"io"
-	"net/http"
-	"time"
 
+	"git.nunosempere.com/NunoSempere/news/lib/feeds"
 	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
 )
 
-// TODO: Define any custom types needed for your source's API/RSS response
+// TODO: Define any custom types needed for your source's API response
 type APIResponse struct {
 	// Add fields based on your source's response format
 }
 
-type RSSResponse struct {
-	XMLName xml.Name `xml:"rss"`
-	Channel Channel  `xml:"channel"`
-}
-
-type Channel struct {
-	Items []RSSItem `xml:"item"`
-}
-
-type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
-}
-
 // FetchSources retrieves sources from {{SOURCE_NAME}}
 // TODO: Implement your source-specific fetching logic
 func FetchSources() ([]types.Source, error) {
@@ -49,46 +31,14 @@ func FetchSources() ([]types.Source, error) {
 	return sources, nil
 }
 
-// fetchFromRSS fetches sources from an RSS feed
+// fetchFromRSS fetches sources from an RSS, Atom, RDF or JSON Feed feed
 func fetchFromRSS(url string) ([]types.Source, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var rss RSSResponse
-	if err := xml.Unmarshal(data, &rss); err != nil {
-		return nil, err
-	}
-
-	var sources []types.Source
-	for _, item := range rss.Channel.Items {
-		// TODO: Parse date in a different format if needed
-		date, err := time.Parse(time.RFC1123Z, item.PubDate)
-		if err != nil {
-			date = time.Now()
-		}
-
-		source := types.Source{
-			Title: item.Title,
-			Link:  item.Link,
-			Date:  date,
-		}
-		sources = append(sources, source)
-	}
-
-	return sources, nil
+	return feeds.FetchSources(url, "{{SOURCE_NAME}}")
 }
 
 // fetchFromAPI fetches sources from a JSON API
 func fetchFromAPI(url string) ([]types.Source, error) {
-	resp, err := http.Get(url)
+	resp, err := web.DefaultClient.Open(url)
 	if err != nil {
 		return nil, err
 	}
@@ -111,13 +61,7 @@ func doSmth(_ interface{}) {
 
 // fetchFromWebpage scrapes sources from a webpage
 func fetchFromWebpage(url string) ([]types.Source, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	content, err := io.ReadAll(resp.Body)
+	content, err := web.Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -23,7 +23,10 @@ func FilterAndExpandSource(source types.Source, openrouter_key string, database_
 		filters.IsGoodHostFilter(),
 		filters.CleanTitleFilter(),
 		filters.ExtractSummaryFilter(openrouter_key),
-		filters.CheckImportanceFilter(openrouter_key),
+		filters.WithRules(filters.CheckImportanceFilter(openrouter_key)),
+		filters.ExtractEventFilter(openrouter_key),
+		filters.GeotagFilter(),
+		filters.TrackStoryFilter(openrouter_key, database_url),
 	}
 	es, ok := filters.ApplyFilters(es, fs)
 
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/config"
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+)
+
+const accountsFile = "accounts.json"
+
+// AccountConfig is one entry in accounts.json
+type AccountConfig struct {
+	Account           string   `json:"account"`                      // handle, without the @
+	GroupHours        int      `json:"group_hours"`                  // tweets are collected into one item per window of this many hours; 0 makes each tweet its own item
+	ImportanceProfile string   `json:"importance_profile,omitempty"` // see lib/llm/profiles.json; picked from each item's content if empty
+	PromoteKeywords   []string `json:"promote_keywords,omitempty"`   // tweets containing any of these (case-insensitive) become items of their own
+	SaveToAIDatabase  bool     `json:"save_to_ai_database,omitempty"`
+	Disabled          bool     `json:"disabled,omitempty"`
+}
+
+func (account AccountConfig) Window() time.Duration {
+	return time.Duration(account.GroupHours) * time.Hour
+}
+
+func (account AccountConfig) IsPromoted(tweet Tweet) bool {
+	if account.GroupHours == 0 {
+		return true
+	}
+	text := strings.ToLower(tweet.Text)
+	for _, keyword := range account.PromoteKeywords {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadAccounts reads the accounts to follow from accounts.json. Handles may start with an @.
+func LoadAccounts() ([]AccountConfig, error) {
+	return config.LoadList(accountsFile, "account", validateAccount, func(account AccountConfig) bool { return account.Disabled })
+}
+
+func validateAccount(account *AccountConfig) ([]string, error) {
+	account.Account = strings.TrimPrefix(account.Account, "@")
+	if account.Account == "" {
+		return nil, fmt.Errorf("has no handle")
+	}
+	if account.GroupHours < 0 {
+		return nil, fmt.Errorf("%s has a negative group_hours", account.Account)
+	}
+	if _, ok := llm.GetProfile(account.ImportanceProfile); account.ImportanceProfile != "" && !ok {
+		return nil, fmt.Errorf("%s has an unknown importance_profile %q", account.Account, account.ImportanceProfile)
+	}
+	// Handles aren't case-sensitive
+	return []string{"@" + strings.ToLower(account.Account)}, nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+type TweetsAPIResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    struct {
+		Tweets []Tweet `json:"tweets"`
+		Count  int     `json:"count"`
+	} `json:"data"`
+}
+
+type Tweet struct {
+	TweetID   string    `json:"tweet_id"`
+	Text      string    `json:"text"`
+	CreatedAt string    `json:"created_at"`
+	Username  string    `json:"username"`
+	Time      time.Time `json:"-"` // CreatedAt, parsed
+}
+
+func (tweet Tweet) URL() string {
+	return fmt.Sprintf("https://x.com/%s/status/%s", tweet.Username, tweet.TweetID)
+}
+
+// Promoted tweets are looked at for this long after being posted
+const promotedLookback = 24 * time.Hour
+
+// Windows are aligned to a Monday at midnight UTC, so that weekly windows are ISO weeks
+var windowOrigin = time.Date(1970, 1, 5, 0, 0, 0, 0, time.UTC)
+
+func windowStart(t time.Time, window time.Duration) time.Time {
+	return windowOrigin.Add(t.Sub(windowOrigin) / window * window)
+}
+
+// FetchTweets gets the latest tweets of an account from tweets.nunosempere.com, oldest first
+func FetchTweets(account string) ([]Tweet, error) {
+	url := fmt.Sprintf("https://tweets.nunosempere.com/api/tweets/%s?limit=1000", account)
+	resp, err := web.DefaultClient.Open(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var api_response TweetsAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&api_response); err != nil {
+		return nil, err
+	}
+	if !api_response.Success {
+		return nil, fmt.Errorf("API returned error: %s", api_response.Message)
+	}
+
+	var tweets []Tweet
+	for _, tweet := range api_response.Data.Tweets {
+		created_at, err := time.Parse(time.RFC3339, tweet.CreatedAt)
+		if err != nil {
+			log.Printf("Skipping tweet %s with malformed date %q", tweet.TweetID, tweet.CreatedAt)
+			continue
+		}
+		tweet.Time = created_at
+		if tweet.Username == "" {
+			tweet.Username = account
+		}
+		tweets = append(tweets, tweet)
+	}
+	sort.Slice(tweets, func(i, j int) bool { return tweets[i].Time.Before(tweets[j].Time) })
+	return tweets, nil
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n]) + "..."
+	}
+	return s
+}
+
+// tweetSource makes an item out of a single promoted tweet
+func tweetSource(account AccountConfig, tweet Tweet) types.Source {
+	return types.Source{
+		Title:   fmt.Sprintf("@%s: %s", account.Account, truncate(tweet.Text, 100)),
+		Link:    tweet.URL(),
+		Date:    tweet.Time,
+		Origin:  "@" + account.Account + " tweets",
+		Content: tweet.Text,
+	}
+}
+
+// groupSource makes an item out of a window's tweets, linked to its first tweet
+func groupSource(account AccountConfig, start time.Time, tweets []Tweet) types.Source {
+	end := start.Add(account.Window())
+	period := fmt.Sprintf("%s to %s", start.Format("Jan 2 15:04"), end.Format("Jan 2 15:04 2006 UTC"))
+	if account.GroupHours%24 == 0 {
+		period = fmt.Sprintf("%s to %s", start.Format("Jan 2"), end.Add(-time.Second).Format("Jan 2 2006"))
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "# @%s tweets, %s\n\n", account.Account, period)
+	for _, tweet := range tweets {
+		fmt.Fprintf(&content, "## %s\n\n%s\n\n[Original tweet](%s)\n\n---\n\n", tweet.Time.UTC().Format("Jan 2, 2006 15:04 UTC"), tweet.Text, tweet.URL())
+	}
+
+	return types.Source{
+		Title:   fmt.Sprintf("@%s tweets, %s (%d tweets)", account.Account, period, len(tweets)),
+		Link:    tweets[0].URL(),
+		Date:    tweets[0].Time,
+		Origin:  "@" + account.Account + " tweets",
+		Content: content.String(),
+	}
+}
+
+// FetchSources makes items out of an account's promoted tweets of the last day, and out of
+// its other tweets in the latest window to have ended. Tweets in the current window wait until it ends.
+func FetchSources(account AccountConfig, now time.Time) ([]types.Source, error) {
+	tweets, err := FetchTweets(account.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []types.Source
+	var grouped []Tweet
+	for _, tweet := range tweets {
+		if account.IsPromoted(tweet) {
+			if now.Sub(tweet.Time) < promotedLookback {
+				sources = append(sources, tweetSource(account, tweet))
+			}
+			continue
+		}
+		grouped = append(grouped, tweet)
+	}
+	if account.GroupHours == 0 {
+		return sources, nil
+	}
+
+	window := account.Window()
+	latest_ended := windowStart(now, window).Add(-window)
+	var in_window []Tweet
+	for _, tweet := range grouped {
+		if windowStart(tweet.Time, window).Equal(latest_ended) {
+			in_window = append(in_window, tweet)
+		}
+	}
+	if len(in_window) > 0 {
+		sources = append(sources, groupSource(account, latest_ended, in_window))
+	}
+	return sources, nil
+}
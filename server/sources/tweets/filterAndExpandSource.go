@@ -0,0 +1,29 @@
+package main
+
+import (
+	"git.nunosempere.com/NunoSempere/news/lib/filters"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// FilterAndExpandSource summarizes the tweets an item carries, rather than the page at its link,
+// and checks their importance with the account's profile
+func FilterAndExpandSource(source types.Source, account AccountConfig, openrouter_key string, database_url string) (types.ExpandedSource, bool) {
+	es := types.ExpandedSource{
+		Title:   source.Title,
+		Link:    source.Link,
+		Date:    source.Date,
+		Origin:  source.Origin,
+		Content: source.Content,
+	}
+
+	fs := []types.Filter{
+		filters.IsFreshFilter(),
+		filters.IsDupeFilter(database_url),
+		filters.ExtractSummaryFilter(openrouter_key),
+		filters.WithRules(filters.ProfileImportanceFilter(account.ImportanceProfile, openrouter_key)),
+		filters.ExtractEventFilter(openrouter_key),
+		filters.GeotagFilter(),
+		filters.TrackStoryFilter(openrouter_key, database_url),
+	}
+	return filters.ApplyFilters(es, fs)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/pgx"
+	"github.com/joho/godotenv"
+)
+
+const pollInterval = 1 * time.Hour
+
+func main() {
+	// Set up logging
+	logFile, err := os.OpenFile("v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Load environment variables
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openrouter_key := os.Getenv("OPENROUTER_API_KEY")
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	accounts, err := LoadAccounts()
+	if err != nil {
+		log.Fatalf("Error loading accounts: %v", err)
+	}
+
+	// Items are looked at again on each poll until their window moves on, so remember which we have processed.
+	// Items dropped because e.g. the LLM was down aren't, so that they are tried again on the next poll.
+	// Kept items are also caught by the duplicate filter after a restart.
+	processed := map[string]bool{}
+	for {
+		for _, account := range accounts {
+			sources, err := FetchSources(account, time.Now())
+			if err != nil {
+				log.Printf("Error fetching tweets from @%s: %v", account.Account, err)
+				continue
+			}
+
+			for _, source := range sources {
+				if processed[source.Link] {
+					continue
+				}
+				log.Printf("\nProcessing: %s", source.Title)
+
+				es, ok := FilterAndExpandSource(source, account, openrouter_key, pg_database_url)
+				if !ok && es.Failed {
+					log.Printf("Will try again on the next poll")
+					continue
+				}
+				processed[source.Link] = true
+				if ok {
+					pgx.SaveSource(es)
+				}
+				if account.SaveToAIDatabase && es.Summary != "" {
+					pgx.SaveToAIDatabase(es)
+				}
+			}
+		}
+
+		log.Printf("Finished processing tweets, sleeping for %v", pollInterval)
+		time.Sleep(pollInterval)
+	}
+}
@@ -1,92 +1,139 @@
 package main
 
 import (
-    "encoding/xml"
-    "io"
-    "net/http"
-    "strings"
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"github.com/PuerkitoBio/goquery"
 )
 
-// RSS represents the root RSS structure
-type RSS struct {
-    XMLName xml.Name `xml:"rss"`
-    Channel Channel  `xml:"channel"`
-}
+// The portal shows the last seven days, newest first
+const currentEventsURL = "https://en.wikipedia.org/wiki/Portal:Current_events"
 
-// Channel represents the channel element in RSS
-type Channel struct {
-    Items []Item `xml:"item"`
+// Event is one bullet of Wikipedia's current events
+type Event struct {
+	Date     time.Time
+	Category string   // e.g. "Armed conflicts and attacks"
+	Topics   []string // the story headings the bullet is nested under, outermost first, e.g. ["Russian invasion of Ukraine"]
+	Text     string   // the bullet's wording, without its citations
+	Links    []string // the articles it cites
 }
 
-// Item represents each news item
-type Item struct {
-    Link string `xml:"link"`
+// FetchCurrentEvents gets and parses the current events portal
+func FetchCurrentEvents() ([]Event, error) {
+	page, err := web.Get(currentEventsURL)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCurrentEvents(page)
 }
 
-// ExtractCurrentEventsLink gets the most recent current events link from the RSS feed URL
-func ExtractCurrentEventsLink(url string) (string, error) {
-    // Fetch the RSS feed
-    resp, err := http.Get(url)
-    if err != nil {
-        return "", err
-    }
-    defer resp.Body.Close()
+// ParseCurrentEvents walks the portal's day sections. Each day has category headings
+// followed by nested lists, where the outer items name a story and the innermost ones are events.
+func ParseCurrentEvents(page []byte) ([]Event, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return nil, err
+	}
 
-    // Read and parse the XML
-    data, err := io.ReadAll(resp.Body)
-    if err != nil {
-        return "", err
-    }
+	var events []Event
+	days := doc.Find("div.current-events-main")
+	if days.Length() == 0 {
+		return nil, fmt.Errorf("no day sections found; has the portal's layout changed?")
+	}
+	days.Each(func(_ int, day *goquery.Selection) {
+		date, ok := dayDate(day)
+		if !ok {
+			return
+		}
+		category := ""
+		day.Find("div.current-events-content").Children().Each(func(_ int, child *goquery.Selection) {
+			switch {
+			case child.Is("ul"):
+				events = append(events, listEvents(child, date, category, nil)...)
+			case child.Is("p, .current-events-content-heading"):
+				// Older days use <p><b>Category</b></p>, newer ones a heading div
+				if heading := cleanText(child.Text()); heading != "" {
+					category = heading
+				}
+			}
+		})
+	})
+	return events, nil
+}
 
-    var rss RSS
-    if err := xml.Unmarshal(data, &rss); err != nil {
-        return "", err
-    }
+// dayDate reads a day section's id, like "2025_January_15", or else its title
+func dayDate(day *goquery.Selection) (time.Time, bool) {
+	if id, ok := day.Attr("id"); ok {
+		if date, err := time.Parse("2006_January_2", id); err == nil {
+			return date, true
+		}
+	}
+	title := cleanText(day.Find(".current-events-title .summary").First().Text())
+	title, _, _ = strings.Cut(title, " (")
+	if date, err := time.Parse("January 2, 2006", title); err == nil {
+		return date, true
+	}
+	return time.Time{}, false
+}
 
-    // Get the first item's link (most recent)
-    if len(rss.Channel.Items) > 0 {
-        return rss.Channel.Items[0].Link, nil
-    }
+// listEvents turns the items of a list into events. An item with its own citations is an event;
+// an item with a nested list is a story heading for the items under it.
+func listEvents(list *goquery.Selection, date time.Time, category string, topics []string) []Event {
+	var events []Event
+	list.ChildrenFiltered("li").Each(func(_ int, item *goquery.Selection) {
+		own := item.Clone()
+		own.Find("ul").Remove()
 
-    return "", nil
-}
+		var links []string
+		own.Find("a.external").Each(func(_ int, a *goquery.Selection) {
+			if href, ok := a.Attr("href"); ok && !strings.Contains(href, "wikipedia.org") && !strings.Contains(href, "wikimedia") {
+				links = append(links, href)
+			}
+		})
+		own.Find("a.external, sup.reference").Remove()
+		text := cleanText(own.Text())
 
-// ExtractExternalLinks gets all external news source links from the content
-func ExtractExternalLinks(content string) []string {
-    var links []string
-    
-    // Simple string-based extraction for external links
-    // Look for class="external text" href="..."
-    parts := strings.Split(content, `class="external text"`)
-    for _, part := range parts[1:] { // Skip first part before first match
-        if idx := strings.Index(part, `href="`); idx >= 0 {
-            part = part[idx+6:] // Skip 'href="'
-            if idx = strings.Index(part, `"`); idx >= 0 {
-                link := part[:idx]
-                // Filter out Wikipedia and Wikimedia links
-                if !strings.Contains(link, "wikipedia.org") && 
-                   !strings.Contains(link, "wikimediafoundation.org") {
-                    links = append(links, link)
-                }
-            }
-        }
-    }
-    
-    return links
+		if len(links) > 0 && text != "" {
+			events = append(events, Event{
+				Date:     date,
+				Category: category,
+				Topics:   topics,
+				Text:     text,
+				Links:    links,
+			})
+		}
+		item.ChildrenFiltered("ul").Each(func(_ int, nested *goquery.Selection) {
+			nested_topics := topics
+			if text != "" {
+				nested_topics = append(append([]string{}, topics...), text)
+			}
+			events = append(events, listEvents(nested, date, category, nested_topics)...)
+		})
+	})
+	return events
 }
 
-// FetchCurrentEvents gets the content of the current events page
-func FetchCurrentEvents(url string) (string, error) {
-    resp, err := http.Get(url)
-    if err != nil {
-        return "", err
-    }
-    defer resp.Body.Close()
-
-    content, err := io.ReadAll(resp.Body)
-    if err != nil {
-        return "", err
-    }
+// cleanText collapses whitespace, and the empty parentheses left behind by removed citations
+func cleanText(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.ReplaceAll(s, "()", "")
+	s = strings.ReplaceAll(s, " .", ".")
+	s = strings.ReplaceAll(s, " ,", ",")
+	return strings.TrimSpace(strings.Join(strings.Fields(s), " "))
+}
 
-    return string(content), nil
+// Title is the event's first sentence
+func (e Event) Title() string {
+	title := e.Text
+	if i := strings.Index(title, ". "); i > 0 {
+		title = title[:i+1]
+	}
+	if runes := []rune(title); len(runes) > 200 {
+		title = string(runes[:200]) + "..."
+	}
+	return title
 }
@@ -1,33 +1,89 @@
 package main
 
 import (
+	"log"
+	"slices"
+	"strings"
+
+	"git.nunosempere.com/NunoSempere/news/lib/domains"
 	"git.nunosempere.com/NunoSempere/news/lib/filters"
 	"git.nunosempere.com/NunoSempere/news/lib/types"
 )
 
-// FilterAndExpandSource processes a wikinews source through various filters,
-// expands its content (via summarization and importance check),
-// and returns an ExpandedSource and a boolean indicating if it passes thresholds.
-func FilterAndExpandSource(source types.Source, openrouter_key string, database_url string) (types.ExpandedSource, bool) {
-	// Since wikinews external links don't provide a publication date,
-	// we use the current time and assume freshness.
-	es := types.ExpandedSource{
-		Title:  source.Title,
-		Link:   source.Link,
-		Date:   source.Date,
-		Origin: source.Origin,
+// What each of Wikipedia's categories tends to mean for us, given to the LLM as a prior
+var category_priors = map[string]string{
+	"Armed conflicts and attacks": "Most attacks are local; what matters is escalation between major or nuclear powers, new fronts, and attacks on critical infrastructure.",
+	"Disasters and accidents":     "Most disasters are local; what matters is very large death tolls, industrial or nuclear accidents, and disasters which could cascade.",
+	"Health and environment":      "Outbreaks of novel or unusual pathogens, and of known pathogens in new places, matter a lot, even when small.",
+	"International relations":     "Changes in alliances, arms control and relations between nuclear powers matter; routine diplomacy does not.",
+	"Science and technology":      "Advances in AI, biotechnology and weapons matter; most other science news does not.",
+	"Politics and elections":      "Only matters if it changes how a major power handles war, weapons or pandemics.",
+	"Law and crime":               "Rarely matters, except for e.g. terrorism or the proliferation of weapons.",
+	"Business and economy":        "Rarely matters, except for e.g. financial crises or disruptions of critical supply chains.",
+}
+
+// Events in these categories are never existentially important, so they aren't sent to the LLM
+var skipped_categories = []string{"Arts and culture", "Sports"}
+
+// EventToSource makes a source out of an event, keyed by its first citation from a site we don't block
+func EventToSource(event Event) types.ExpandedSource {
+	link := event.Links[0]
+	for _, cited := range event.Links {
+		if domains.TierFor(cited) != domains.TierBlock {
+			link = cited
+			break
+		}
+	}
+	summary := event.Text
+	if len(event.Topics) > 0 {
+		summary = strings.Join(event.Topics, " > ") + ": " + summary
+	}
+	return types.ExpandedSource{
+		Title:   event.Title(),
+		Link:    link,
+		Date:    event.Date,
+		Summary: summary,
+		Origin:  "Wikipedia current events",
+	}
+}
+
+func categoryFilter(event Event) types.Filter {
+	return func(source types.ExpandedSource) (types.ExpandedSource, bool) {
+		if slices.Contains(skipped_categories, event.Category) {
+			log.Printf("Filtered because: category is %s", event.Category)
+			return source, false
+		}
+		return source, true
+	}
+}
+
+// importanceNote gives the importance check the event's category, and what usually matters in it
+func importanceNote(event Event) string {
+	if event.Category == "" {
+		return ""
 	}
+	note := "Note about this source: Wikipedia lists this event under \"" + event.Category + "\"."
+	if prior, ok := category_priors[event.Category]; ok {
+		note += " " + prior
+	}
+	return note
+}
+
+// FilterAndExpandSource filters an event once, using Wikipedia's wording as its summary
+// rather than fetching and summarizing each of the articles it cites.
+func FilterAndExpandSource(event Event, openrouter_key string, database_url string) (types.ExpandedSource, bool) {
+	es := EventToSource(event)
 
-	// Apply standard filters (skip freshness check since we assume fresh)
 	filters_list := []types.Filter{
+		categoryFilter(event),
 		filters.IsFreshFilter(),
 		filters.IsDupeFilter(database_url),
-		filters.CleanTitleFilter(),
-		filters.ExtractBetterTitle(),
-		filters.ExtractSummaryFilter(openrouter_key),
-		filters.CheckImportanceFilter(openrouter_key),
+		filters.IsGoodHostFilter(),
+		filters.WithRules(filters.ProfileImportanceFilterWithNote("", importanceNote(event), openrouter_key)),
+		filters.ExtractEventFilter(openrouter_key),
+		filters.GeotagFilter(),
+		filters.TrackStoryFilter(openrouter_key, database_url),
 	}
 	es, ok := filters.ApplyFilters(es, filters_list)
 	return es, ok
-
 }
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"git.nunosempere.com/NunoSempere/news/lib/pgx"
-	"git.nunosempere.com/NunoSempere/news/lib/types"
 	"github.com/joho/godotenv"
 )
 
@@ -31,48 +30,23 @@ func main() {
 
 	for {
 		log.Println("Starting Wikipedia current events processing")
-		rssURL := "https://www.to-rss.xyz/wikipedia/current_events/"
-
-		link, err := ExtractCurrentEventsLink(rssURL)
+		events, err := FetchCurrentEvents()
 		if err != nil {
-			log.Printf("Error extracting current events link: %v", err)
-			time.Sleep(1 * time.Hour)
-			continue
-		}
-		if link == "" {
-			log.Printf("No current events link found")
-			time.Sleep(1 * time.Hour)
-			continue
-		}
-		log.Printf("Current events link: %s", link)
-
-		// Fetch the content
-		content, err := FetchCurrentEvents(link)
-		if err != nil {
-			time.Sleep(1 * time.Hour)
 			log.Printf("Error fetching current events: %v", err)
+			time.Sleep(1 * time.Hour)
 			continue
 		}
+		log.Printf("Found %d events", len(events))
 
-		// Extract and process external links
-		externalLinks := ExtractExternalLinks(content)
-		log.Printf("Found %d external news source links", len(externalLinks))
-
-		// Process each external link
-		for i, extLink := range externalLinks {
-			log.Printf("\nProcessing link %d/%d: %s", i+1, len(externalLinks), extLink)
-			source := types.Source{
-				Title: extLink,
-				Link:  extLink,
-				Date:  time.Now(), // FilterAndExpandSource will set date
-			}
-			expanded_source, passes_filters := FilterAndExpandSource(source, openrouter_key, pg_database_url)
+		for i, event := range events {
+			log.Printf("\nProcessing event %d/%d (%s, %s): %s", i+1, len(events), event.Date.Format("2006-01-02"), event.Category, event.Text)
+			expanded_source, passes_filters := FilterAndExpandSource(event, openrouter_key, pg_database_url)
 			if passes_filters {
 				pgx.SaveSource(expanded_source)
 			}
 		}
 
-		log.Printf("Finished processing current events, sleeping for 24 hours")
+		log.Printf("Finished processing current events, sleeping for 12 hours")
 		time.Sleep(12 * time.Hour)
 	}
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"git.nunosempere.com/NunoSempere/news/lib/golden"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+// fixtures/web is synthetic for now; see fixtures/README.md
+func TestMain(m *testing.M) {
+	web.SetMode(web.ModeReplay, "fixtures/web")
+	os.Exit(m.Run())
+}
+
+func TestFetchCurrentEvents(t *testing.T) {
+	events, err := FetchCurrentEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "events", events)
+
+	titles := map[string]string{}
+	for _, event := range events {
+		titles[event.Text] = event.Title()
+	}
+	golden.Check(t, "titles", titles)
+}
+
+func TestParseCurrentEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     string
+		want_err bool
+	}{
+		{"no_days", `<html><body><p>The portal moved</p></body></html>`, true},
+		{"empty_day", `<div class="current-events-main" id="2025_May_13"><div class="current-events-content"></div></div>`, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events, err := ParseCurrentEvents([]byte(test.page))
+			if (err != nil) != test.want_err {
+				t.Fatalf("expected error: %v, got %v", test.want_err, err)
+			}
+			if len(events) != 0 {
+				t.Errorf("expected no events, got %d", len(events))
+			}
+		})
+	}
+}
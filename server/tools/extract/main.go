@@ -0,0 +1,153 @@
+package main
+
+// Show what the extractor rules in lib/extractors get out of a page, to check a new or changed rule.
+// Usage:
+//   go run main.go -fixture fixtures/dsca.mil.html     (the domain is taken from the file name, unless -url is given)
+//   go run main.go -url https://mil.gmw.cn/...         (fetches the page live)
+//   go run main.go -url https://mil.gmw.cn/... -save fixtures/gmw.cn.html
+//   go run main.go -all                                 (every fixture in fixtures/)
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"git.nunosempere.com/NunoSempere/news/lib/extractors"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"github.com/PuerkitoBio/goquery"
+)
+
+const fixturesDir = "fixtures"
+
+// showMatches prints how many elements each alternative of a selector matches
+func showMatches(doc *goquery.Document, field string, selector string) {
+	if selector == "" {
+		return
+	}
+	for _, alternative := range strings.Split(selector, ",") {
+		alternative = strings.TrimSpace(alternative)
+		fmt.Printf("  %-6s %-50s %d matches\n", field, alternative, doc.Find(alternative).Length())
+	}
+}
+
+func show(link string, html []byte) bool {
+	rule, ok := extractors.RuleFor(link)
+	if !ok {
+		fmt.Printf("No rule for %s\n", link)
+		return false
+	}
+	fmt.Printf("Rule %q for %s\n", rule.Name, link)
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		fmt.Printf("Error parsing html: %v\n", err)
+		return false
+	}
+	showMatches(doc, "title", rule.Title)
+	showMatches(doc, "date", rule.Date)
+	showMatches(doc, "body", rule.Body)
+	for _, selector := range rule.Remove {
+		showMatches(doc, "remove", selector)
+	}
+
+	extracted, err := extractors.Extract(html, rule)
+	fmt.Printf("\nTitle: %s\n", extracted.Title)
+	if extracted.HasDate {
+		fmt.Printf("Date:  %v\n", extracted.Date)
+	} else {
+		fmt.Printf("Date:  (none)\n")
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n\n", err)
+		return false
+	}
+	paragraphs := strings.Split(extracted.Body, "\n")
+	fmt.Printf("Body:  %d paragraphs, %d characters\n", len(paragraphs), len(extracted.Body))
+	for i, paragraph := range paragraphs {
+		if i == 5 {
+			fmt.Printf("  ...\n")
+			break
+		}
+		if len([]rune(paragraph)) > 120 {
+			paragraph = string([]rune(paragraph)[:120]) + "..."
+		}
+		fmt.Printf("  | %s\n", paragraph)
+	}
+	fmt.Println()
+	return true
+}
+
+// linkFromFixture turns e.g. fixtures/dsca.mil.html into https://dsca.mil/
+func linkFromFixture(path string) string {
+	return "https://" + strings.TrimSuffix(filepath.Base(path), ".html") + "/"
+}
+
+func main() {
+	link := flag.String("url", "", "page url; fetched live unless -fixture is given")
+	fixture := flag.String("fixture", "", "saved html page")
+	save := flag.String("save", "", "save the fetched page here, to use as a fixture")
+	all := flag.Bool("all", false, "check every fixture in "+fixturesDir)
+	rules := flag.String("rules", "", "rules file to use instead of the embedded lib/extractors/rules.json")
+	flag.Parse()
+
+	if *rules != "" {
+		if err := extractors.LoadRules(*rules); err != nil {
+			log.Fatalf("Error loading rules: %v", err)
+		}
+	}
+
+	if *all {
+		paths, err := filepath.Glob(filepath.Join(fixturesDir, "*.html"))
+		if err != nil || len(paths) == 0 {
+			log.Fatalf("No fixtures found in %s", fixturesDir)
+		}
+		n_failed := 0
+		for _, path := range paths {
+			html, err := os.ReadFile(path)
+			if err != nil {
+				log.Fatalf("Error reading %s: %v", path, err)
+			}
+			fmt.Printf("=== %s\n", path)
+			if !show(linkFromFixture(path), html) {
+				n_failed++
+			}
+		}
+		fmt.Printf("%d/%d fixtures extracted\n", len(paths)-n_failed, len(paths))
+		if n_failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	var html []byte
+	var err error
+	switch {
+	case *fixture != "":
+		html, err = os.ReadFile(*fixture)
+		if *link == "" {
+			*link = linkFromFixture(*fixture)
+		}
+	case *link != "":
+		html, err = web.Get(*link)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("Error getting page: %v", err)
+	}
+
+	if *save != "" {
+		if err := os.WriteFile(*save, html, 0644); err != nil {
+			log.Fatalf("Error saving fixture: %v", err)
+		}
+		log.Printf("Saved %s", *save)
+	}
+	if !show(*link, html) {
+		os.Exit(1)
+	}
+}
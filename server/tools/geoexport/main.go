@@ -0,0 +1,206 @@
+package main
+
+// Export the last week's important events as GeoJSON or KML, to eyeball where they concentrate on a map.
+// Usage: go run main.go -format geojson -days 7 -out events.geojson
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/joho/godotenv"
+)
+
+type MappedEvent struct {
+	Title     string
+	Link      string
+	Date      time.Time
+	EventType string
+	Killed    *int
+	Locations []types.Location
+}
+
+func getMappedEvents(database_url string, days int) ([]MappedEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return nil, err
+	}
+	defer conn.Close(context.Background())
+
+	rows, err := conn.Query(ctx, `
+		SELECT title, link, date, COALESCE(event_type, ''), killed, locations
+		FROM sources
+		WHERE importance_bool = true
+		  AND locations IS NOT NULL
+		  AND date > $1
+		ORDER BY date DESC
+	`, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Printf("Error querying events: %v\n", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []MappedEvent
+	for rows.Next() {
+		var e MappedEvent
+		var locations []byte
+		if err := rows.Scan(&e.Title, &e.Link, &e.Date, &e.EventType, &e.Killed, &locations); err != nil {
+			log.Printf("Error scanning event: %v\n", err)
+			return nil, err
+		}
+		if err := json.Unmarshal(locations, &e.Locations); err != nil {
+			log.Printf("Skipping event with bad locations (%s): %v\n", e.Link, err)
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+type GeoJSONFeature struct {
+	Type       string         `json:"type"`
+	Geometry   map[string]any `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// One point per (event, location) pair, so that events touching several places show up in each of them
+func writeGeoJSON(w io.Writer, events []MappedEvent) error {
+	collection := GeoJSONFeatureCollection{Type: "FeatureCollection", Features: []GeoJSONFeature{}}
+	for _, e := range events {
+		for _, location := range e.Locations {
+			properties := map[string]any{
+				"title":        e.Title,
+				"link":         e.Link,
+				"date":         e.Date.Format(time.RFC3339),
+				"location":     location.Name,
+				"country_code": location.CountryCode,
+			}
+			if e.EventType != "" {
+				properties["event_type"] = e.EventType
+			}
+			if e.Killed != nil {
+				properties["killed"] = *e.Killed
+			}
+			collection.Features = append(collection.Features, GeoJSONFeature{
+				Type:       "Feature",
+				Geometry:   map[string]any{"type": "Point", "coordinates": []float64{location.Lon, location.Lat}},
+				Properties: properties,
+			})
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(collection)
+}
+
+type KMLPlacemark struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	When        string `xml:"TimeStamp>when"`
+	Coordinates string `xml:"Point>coordinates"`
+}
+
+type KML struct {
+	XMLName    xml.Name       `xml:"kml"`
+	Namespace  string         `xml:"xmlns,attr"`
+	Name       string         `xml:"Document>name"`
+	Placemarks []KMLPlacemark `xml:"Document>Placemark"`
+}
+
+func writeKML(w io.Writer, events []MappedEvent, days int) error {
+	kml := KML{Namespace: "http://www.opengis.net/kml/2.2", Name: fmt.Sprintf("Important events, last %d days", days)}
+	for _, e := range events {
+		description := e.Link
+		if e.EventType != "" {
+			description = e.EventType + "\n" + description
+		}
+		if e.Killed != nil {
+			description = fmt.Sprintf("%d killed\n%s", *e.Killed, description)
+		}
+		for _, location := range e.Locations {
+			kml.Placemarks = append(kml.Placemarks, KMLPlacemark{
+				Name:        e.Title,
+				Description: location.Name + "\n" + description,
+				When:        e.Date.Format(time.RFC3339),
+				Coordinates: fmt.Sprintf("%f,%f", location.Lon, location.Lat),
+			})
+		}
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(kml)
+}
+
+// export writes the events to out, or to stdout if out is "", removing out if writing fails so that no half-written file is left behind
+func export(out string, write func(io.Writer) error) (err error) {
+	if out == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("could not create output file: %w", err)
+	}
+	defer func() {
+		if close_err := f.Close(); err == nil {
+			err = close_err
+		}
+		if err != nil {
+			os.Remove(out)
+		}
+	}()
+	return write(f)
+}
+
+func main() {
+	format := flag.String("format", "geojson", "output format: geojson or kml")
+	days := flag.Int("days", 7, "export events from the last n days")
+	out := flag.String("out", "", "output file (default: stdout)")
+	flag.Parse()
+
+	var write func(io.Writer, []MappedEvent) error
+	switch *format {
+	case "geojson":
+		write = writeGeoJSON
+	case "kml":
+		write = func(w io.Writer, events []MappedEvent) error { return writeKML(w, events, *days) }
+	default:
+		log.Fatalf("Unknown format %q, expected geojson or kml", *format)
+	}
+
+	err := godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	events, err := getMappedEvents(os.Getenv("DATABASE_POOL_URL"), *days)
+	if err != nil {
+		log.Fatalf("Could not get events: %v", err)
+	}
+
+	err = export(*out, func(w io.Writer) error { return write(w, events) })
+	if err != nil {
+		log.Fatalf("Could not write %s: %v", *format, err)
+	}
+	log.Printf("Exported %d events to %s", len(events), *format)
+}
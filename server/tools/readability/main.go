@@ -0,0 +1,160 @@
+package main
+
+// Measure how well lib/readability extracts articles, against the saved pages in fixtures/.
+// Each fixtures/NAME.html has a fixtures/NAME.json saying what should be extracted from it.
+// Usage:
+//   go run main.go              (score every fixture)
+//   go run main.go -v           (and show the extracted text)
+//   go run main.go -url https://...  (show what is extracted from a live page)
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"git.nunosempere.com/NunoSempere/news/lib/readability"
+)
+
+const fixturesDir = "fixtures"
+
+type Expected struct {
+	Title          string   `json:"title"`
+	Byline         string   `json:"byline,omitempty"`
+	Published      string   `json:"published,omitempty"` // YYYY-MM-DD
+	Language       string   `json:"language,omitempty"`
+	MustContain    []string `json:"must_contain"`     // passages of the article
+	MustNotContain []string `json:"must_not_contain"` // boilerplate: navigation, ads, comments, etc.
+}
+
+type Score struct {
+	Fields    int // title, byline, date and language that are right
+	NFields   int
+	Recall    float64 // fraction of must_contain passages found
+	Precision float64 // fraction of must_not_contain passages not found
+}
+
+func check(name string, ok bool, got string, expected string) int {
+	mark := "ok"
+	if !ok {
+		mark = "WRONG"
+	}
+	fmt.Printf("  %-9s %-5s got %q, expected %q\n", name, mark, got, expected)
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+func evaluate(article readability.Article, expected Expected) Score {
+	score := Score{Recall: 1, Precision: 1}
+
+	score.NFields++
+	score.Fields += check("title", article.Title == expected.Title, article.Title, expected.Title)
+	if expected.Byline != "" {
+		score.NFields++
+		score.Fields += check("byline", strings.Contains(article.Byline, expected.Byline), article.Byline, expected.Byline)
+	}
+	if expected.Published != "" {
+		published := ""
+		if article.HasPublished {
+			published = article.Published.Format("2006-01-02")
+		}
+		score.NFields++
+		score.Fields += check("published", published == expected.Published, published, expected.Published)
+	}
+	if expected.Language != "" {
+		score.NFields++
+		score.Fields += check("language", strings.EqualFold(article.Language, expected.Language), article.Language, expected.Language)
+	}
+
+	if len(expected.MustContain) > 0 {
+		found := 0
+		for _, passage := range expected.MustContain {
+			if strings.Contains(article.Text, passage) {
+				found++
+			} else {
+				fmt.Printf("  missing   %q\n", passage)
+			}
+		}
+		score.Recall = float64(found) / float64(len(expected.MustContain))
+	}
+	if len(expected.MustNotContain) > 0 {
+		clean := 0
+		for _, passage := range expected.MustNotContain {
+			if !strings.Contains(article.Text, passage) {
+				clean++
+			} else {
+				fmt.Printf("  noise     %q\n", passage)
+			}
+		}
+		score.Precision = float64(clean) / float64(len(expected.MustNotContain))
+	}
+	fmt.Printf("  recall %.2f, precision %.2f\n", score.Recall, score.Precision)
+	return score
+}
+
+func show(article readability.Article) {
+	fmt.Printf("Title:     %s\nByline:    %s\nPublished: %v (%t)\nLanguage:  %s\n\n%s\n\n", article.Title, article.Byline, article.Published, article.HasPublished, article.Language, article.Text)
+}
+
+func main() {
+	link := flag.String("url", "", "show what is extracted from this page, instead of scoring the fixtures")
+	verbose := flag.Bool("v", false, "show the extracted text of each fixture")
+	flag.Parse()
+
+	if *link != "" {
+		article, err := readability.FetchArticle(*link, "")
+		show(article)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
+
+	paths, err := filepath.Glob(filepath.Join(fixturesDir, "*.html"))
+	if err != nil || len(paths) == 0 {
+		log.Fatalf("No fixtures found in %s", fixturesDir)
+	}
+
+	var total Score
+	n_failed := 0
+	for _, path := range paths {
+		fmt.Printf("=== %s\n", path)
+		page, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Error reading %s: %v", path, err)
+		}
+		expected_json, err := os.ReadFile(strings.TrimSuffix(path, ".html") + ".json")
+		if err != nil {
+			log.Fatalf("Error reading expectations for %s: %v", path, err)
+		}
+		var expected Expected
+		if err := json.Unmarshal(expected_json, &expected); err != nil {
+			log.Fatalf("Error parsing expectations for %s: %v", path, err)
+		}
+
+		article, err := readability.Parse(page)
+		if err != nil {
+			fmt.Printf("  error     %v\n", err)
+		}
+		if *verbose {
+			show(article)
+		}
+		score := evaluate(article, expected)
+		total.Fields += score.Fields
+		total.NFields += score.NFields
+		total.Recall += score.Recall
+		total.Precision += score.Precision
+		if err != nil || score.Fields < score.NFields || score.Recall < 1 || score.Precision < 1 {
+			n_failed++
+		}
+	}
+
+	n := float64(len(paths))
+	fmt.Printf("\n%d fixtures, %d fully right. Metadata fields: %d/%d. Mean recall %.2f, mean precision %.2f\n",
+		len(paths), len(paths)-n_failed, total.Fields, total.NFields, total.Recall/n, total.Precision/n)
+}